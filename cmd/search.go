@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/engine"
+	"github.com/abenz1267/walker/internal/history"
+	"github.com/abenz1267/walker/internal/modules"
+)
+
+// search runs a query without any window and prints the ranked entries. It returns the exit code.
+func search(args []string) int {
+	term, _ := argValue(args, "-S", "--search")
+
+	err := config.Get("")
+	if err != nil {
+		log.Println(err)
+
+		// there's no config to fall back to if it couldn't be written
+		if config.Cfg == nil {
+			config.Default()
+		}
+	}
+
+	hstry := history.Get()
+
+	p := engine.Available(hstry, "", false)
+
+	if names, ok := argValue(args, "-m", "--modules"); ok && names != "" {
+		explicits := []modules.Workable{}
+
+		for _, v := range strings.Split(names, ",") {
+			m := engine.Find(v, p)
+			if m == nil {
+				fmt.Fprintf(os.Stderr, "Module not found: %s\n", v)
+				return 1
+			}
+
+			explicits = append(explicits, m)
+		}
+
+		p = explicits
+	}
+
+	engine.PrepareBlacklists(p)
+
+	keepSort := slices.Contains(args, "-k") || slices.Contains(args, "--keepsort")
+
+	if len(p) == 1 {
+		keepSort = keepSort || p[0].General().KeepSort
+	}
+
+	entries := engine.Run(context.Background(), engine.Query{
+		Text:     term,
+		Modules:  p,
+		KeepSort: keepSort,
+		History:  hstry,
+		Context:  engine.CurrentContext(),
+	})
+
+	results := []engine.Result{}
+
+	for _, v := range entries {
+		results = append(results, engine.ToResult(v))
+	}
+
+	if slices.Contains(args, "-j") || slices.Contains(args, "--json") {
+		b, err := json.Marshal(results)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+
+		fmt.Println(string(b))
+	} else {
+		for _, v := range results {
+			fmt.Printf("%s\t%s\t%s\t%f\t%s\n", v.Label, v.Sub, v.Exec, v.Score, v.Module)
+		}
+	}
+
+	if len(results) == 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// argValue returns the value of an option given as "-o value", "--option value" or "--option=value".
+func argValue(args []string, short, long string) (string, bool) {
+	for k, v := range args {
+		if strings.HasPrefix(v, long+"=") {
+			return strings.TrimPrefix(v, long+"="), true
+		}
+
+		if v == short || v == long {
+			if k+1 < len(args) {
+				return args[k+1], true
+			}
+
+			return "", true
+		}
+	}
+
+	return "", false
+}
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/history"
 	"github.com/abenz1267/walker/internal/modules"
 	"github.com/abenz1267/walker/internal/modules/clipboard"
 	"github.com/abenz1267/walker/internal/state"
@@ -53,15 +54,13 @@ func main() {
 
 	state := state.Get()
 
-	defer func() {
-		os.Remove(modules.DmenuSocketAddrReply)
-	}()
-
 	appName := "dev.benz.walker"
 
 	var wg sync.WaitGroup
 
-	var cancelled bool
+	var dmenuCode int
+
+	runArgs := os.Args
 
 	if len(os.Args) > 1 {
 		args := os.Args[1:]
@@ -82,6 +81,10 @@ func main() {
 				return
 			}
 
+			if _, ok := argValue(args, "-S", "--search"); ok {
+				os.Exit(search(args))
+			}
+
 			if slices.Contains(args, "-b") || slices.Contains(args, "--benchmark") {
 				fmt.Println("Startup: ", now)
 				state.Benchmark = true
@@ -115,10 +118,12 @@ func main() {
 						wg.Add(1)
 
 						dmenu := modules.Dmenu{}
-						dmenu.Send()
+						session := dmenu.Send()
+
+						runArgs = append(slices.Clip(runArgs), "--dmenusession", session)
 
 						go func(wg *sync.WaitGroup) {
-							cancelled = dmenu.ListenForReply()
+							dmenuCode = dmenu.Wait()
 							wg.Done()
 						}(&wg)
 					}
@@ -144,6 +149,58 @@ Type=Application
 				return
 			}
 
+			if file, ok := argValue(args, "-E", "--export-history"); ok {
+				out := os.Stdout
+
+				if file != "" && file != "-" {
+					f, err := os.Create(file)
+					if err != nil {
+						log.Panicln(err)
+					}
+					defer f.Close()
+
+					out = f
+				}
+
+				err := history.ExportJSON(out)
+				if err != nil {
+					log.Panicln(err)
+				}
+
+				return
+			}
+
+			if file, ok := argValue(args, "-I", "--import-history"); ok {
+				in := os.Stdin
+
+				if file != "" && file != "-" {
+					f, err := os.Open(file)
+					if err != nil {
+						log.Panicln(err)
+					}
+					defer f.Close()
+
+					in = f
+				}
+
+				export, err := history.ReadExport(in)
+				if err != nil {
+					log.Panicln(err)
+				}
+
+				// the service would overwrite the imported history with its own, so it imports it itself
+				_, running, err := ui.SendControl(ui.ControlRequest{Action: ui.ControlActionImport, History: &export})
+				if err != nil {
+					log.Panicln(err)
+				}
+
+				if !running {
+					history.Import(history.Get(), export)
+				}
+
+				return
+			}
+
 			if slices.Contains(args, "-D") || slices.Contains(args, "--disableautostart") {
 				err := os.Remove(filepath.Join(xdg.ConfigHome, "autostart", "walker-service.desktop"))
 				if err != nil {
@@ -172,24 +229,48 @@ Type=Application
 	app.AddMainOption("update-clipboard", 'u', glib.OptionFlagNone, glib.OptionArgString, "update clipboard content", "")
 	app.AddMainOption("placeholder", 'p', glib.OptionFlagNone, glib.OptionArgString, "placeholder text", "")
 	app.AddMainOption("query", 'q', glib.OptionFlagNone, glib.OptionArgString, "initial query", "")
+	app.AddMainOption("multi", 'M', glib.OptionFlagNone, glib.OptionArgNone, "select multiple entries in dmenu mode", "")
+	app.AddMainOption("jsonlines", 'J', glib.OptionFlagNone, glib.OptionArgNone, "read dmenu input as json, one entry per line", "")
+	app.AddMainOption("format", 'F', glib.OptionFlagNone, glib.OptionArgString, "dmenu output format, f.e. 'i' for the index", "the format")
+	app.AddMainOption("dmenusession", 0, glib.OptionFlagHidden, glib.OptionArgString, "dmenu session of the client", "the id")
 	app.AddMainOption("labelcolumn", 'l', glib.OptionFlagNone, glib.OptionArgString, "column to use for the label", "")
 	app.AddMainOption("separator", 't', glib.OptionFlagNone, glib.OptionArgString, "column separator", "")
 	app.AddMainOption("version", 'v', glib.OptionFlagNone, glib.OptionArgNone, "print version", "")
 	app.AddMainOption("forceprint", 'f', glib.OptionFlagNone, glib.OptionArgNone, "forces printing input if no item is selected", "")
 	app.AddMainOption("bench", 'b', glib.OptionFlagNone, glib.OptionArgNone, "prints nanoseconds for start and displaying in both service and client", "")
 	app.AddMainOption("active", 'a', glib.OptionFlagNone, glib.OptionArgString, "active item", "")
+	app.AddMainOption("search", 'S', glib.OptionFlagNone, glib.OptionArgString, "print ranked results for the query without opening a window", "the query")
+	app.AddMainOption("json", 'j', glib.OptionFlagNone, glib.OptionArgNone, "print search results as json", "")
+	app.AddMainOption("export-history", 'E', glib.OptionFlagNone, glib.OptionArgString, "export usage and input history as json, '-' for stdout", "the file")
+	app.AddMainOption("import-history", 'I', glib.OptionFlagNone, glib.OptionArgString, "merge usage and input history from json, '-' for stdin", "the file")
 	app.AddMainOption("enableautostart", 'A', glib.OptionFlagNone, glib.OptionArgNone, "creates a desktop file for autostarting on login", "")
 	app.AddMainOption("disableautostart", 'D', glib.OptionFlagNone, glib.OptionArgNone, "removes the autostart desktop file", "")
 
 	app.Connect("activate", ui.Activate(state))
 
-	app.ConnectCommandLine(func(cmd *gio.ApplicationCommandLine) int {
+	// queued is set when a held back dmenu invocation is handled, it's not queued again then
+	var handleCommandLine func(cmd *gio.ApplicationCommandLine, queued bool) int
+
+	handleCommandLine = func(cmd *gio.ApplicationCommandLine, queued bool) int {
 		if state.Benchmark {
 			fmt.Println("start handle cmd: ", time.Now().UnixMilli())
 		}
 
 		options := cmd.OptionsDict()
 
+		// dmenu sessions are shown one after another, the client waits until it's its turn
+		if state.IsService && options.Contains("dmenu") && !queued {
+			session := ""
+
+			if v := options.LookupValue("dmenusession", glib.NewVariantString("").Type()); v != nil {
+				session = v.String()
+			}
+
+			if ui.QueueDmenu(session, func() { handleCommandLine(cmd, true) }, cmd.Done) {
+				return 0
+			}
+		}
+
 		if options.Contains("bench") {
 			state.Benchmark = true
 		}
@@ -204,6 +285,34 @@ Type=Application
 			labelColumnString := options.LookupValue("labelcolumn", glib.NewVariantString("").Type())
 			separatorString := options.LookupValue("separator", glib.NewVariantString("").Type())
 			activeItemString := options.LookupValue("active", glib.NewVariantString("").Type())
+			formatString := options.LookupValue("format", glib.NewVariantString("").Type())
+			sessionString := options.LookupValue("dmenusession", glib.NewVariantString("").Type())
+
+			if state.Dmenu != nil && sessionString != nil {
+				state.Dmenu.Open(sessionString.String())
+			}
+
+			if labelColumnString != nil && labelColumnString.String() != "" {
+				col, err := strconv.Atoi(labelColumnString.String())
+				if err != nil {
+					log.Println(err)
+
+					// the window isn't opened, so the session ends here and the next one can be shown
+					if state.IsService {
+						ui.EndDmenuSession()
+					}
+
+					cmd.Done()
+
+					return 1
+				}
+
+				if state.Dmenu != nil {
+					state.Dmenu.Config.LabelColumn = col
+				} else {
+					state.DmenuLabelColumn = col
+				}
+			}
 
 			if separatorString != nil && separatorString.String() != "" {
 				if state.Dmenu != nil {
@@ -213,16 +322,19 @@ Type=Application
 				}
 			}
 
-			if labelColumnString != nil && labelColumnString.String() != "" {
-				col, err := strconv.Atoi(labelColumnString.String())
-				if err != nil {
-					log.Panicln(err)
+			if formatString != nil && formatString.String() != "" {
+				if state.Dmenu != nil {
+					state.Dmenu.Config.Format = formatString.String()
+				} else {
+					state.DmenuFormat = formatString.String()
 				}
+			}
 
+			if options.Contains("jsonlines") {
 				if state.Dmenu != nil {
-					state.Dmenu.Config.LabelColumn = col
+					state.Dmenu.Config.JsonLines = true
 				} else {
-					state.DmenuLabelColumn = col
+					state.DmenuJsonLines = true
 				}
 			}
 
@@ -241,6 +353,7 @@ Type=Application
 
 			state.ExplicitModules = append(state.ExplicitModules, "dmenu")
 			state.IsDmenu = true
+			state.DmenuMulti = options.Contains("multi")
 
 		} else {
 			if modulesString != nil && modulesString.String() != "" {
@@ -277,6 +390,10 @@ Type=Application
 		cmd.Done()
 
 		return 0
+	}
+
+	app.ConnectCommandLine(func(cmd *gio.ApplicationCommandLine) int {
+		return handleCommandLine(cmd, false)
 	})
 
 	app.Flags()
@@ -284,6 +401,8 @@ Type=Application
 	if state.IsService {
 		app.Hold()
 
+		go ui.ListenControl()
+
 		signal_chan := make(chan os.Signal, 1)
 		signal.Notify(signal_chan,
 			syscall.SIGHUP,
@@ -297,8 +416,8 @@ Type=Application
 				<-signal_chan
 
 				os.Remove(modules.DmenuSocketAddrGet)
-				os.Remove(modules.DmenuSocketAddrReply)
 				os.Remove(clipboard.ClipboardSocketAddrUpdate)
+				os.Remove(ui.ControlSocketAddr)
 
 				os.Exit(0)
 			}
@@ -309,12 +428,12 @@ Type=Application
 		fmt.Println("start run: ", time.Now().UnixMilli())
 	}
 
-	code := app.Run(os.Args)
+	code := app.Run(runArgs)
 
 	wg.Wait()
 
-	if cancelled {
-		code = 2
+	if dmenuCode != 0 {
+		code = dmenuCode
 	}
 
 	os.Exit(code)
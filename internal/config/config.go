@@ -75,6 +75,9 @@ type Keys struct {
 	RemoveFromHistory   []string            `koanf:"remove_from_history"`
 	ResumeQuery         []string            `koanf:"resume_query"`
 	ToggleExactSearch   []string            `koanf:"toggle_exact_search"`
+	ToggleActions       []string            `koanf:"toggle_actions"`
+	ToggleMark          []string            `koanf:"toggle_mark"`
+	DmenuCustom         []string            `koanf:"dmenu_custom"`
 }
 
 type ActivationModifiers struct {
@@ -189,6 +192,7 @@ type CustomCommand struct {
 	TerminalTitleFlag string   `koanf:"terminal_title_flag"`
 }
 
+// GeneralModule holds the settings shared by all modules.
 type GeneralModule struct {
 	AutoSelect         bool        `koanf:"auto_select"`
 	Blacklist          []Blacklist `koanf:"blacklist"`
@@ -212,11 +216,37 @@ type GeneralModule struct {
 	OnSelect           string      `koanf:"on_select"`
 	OutputPlaceholder  string      `koanf:"output_placeholder"`
 
+	// Matcher is one of "fuzzy" (default), "prefix", "substring", "initials", "regex" or "tokens", which is fzf's
+	// extended search syntax.
+	Matcher string `koanf:"matcher"`
+
+	// FieldWeights weighs the matched fields "label", "sub", "searchable", "searchable2" and "categories", 0 excludes a field.
+	FieldWeights map[string]float64 `koanf:"field_weights"`
+
+	// Frecency configures how the usage history ranks entries.
+	Frecency Frecency `koanf:"frecency"`
+
+	// Timeout is the time in milliseconds the module gets to deliver its entries, 0 means no limit.
+	Timeout int `koanf:"timeout"`
+
 	// internal
 	HasInitialSetup bool `koanf:"-"`
 	IsSetup         bool `koanf:"-"`
 }
 
+// Frecency configures how the usage history ranks the entries of a module.
+type Frecency struct {
+	// Model is "decay" (default), "buckets" or "count".
+	Model string `koanf:"model"`
+
+	// HalfLife is the amount of days after which a use counts half.
+	HalfLife float64 `koanf:"half_life"`
+
+	// Context boosts entries used at a similar time of day, on the same weekday, with the same focused app or in the
+	// same directory.
+	Context bool `koanf:"context"`
+}
+
 type Blacklist struct {
 	Regexp string `koanf:"regexp"`
 	Label  bool   `koanf:"label"`
@@ -309,6 +339,8 @@ type Dmenu struct {
 	GeneralModule `koanf:",squash"`
 	Separator     string `koanf:"separator"`
 	LabelColumn   int    `koanf:"label_column"`
+	JsonLines     bool   `koanf:"json_lines"`
+	Format        string `koanf:"format"`
 }
 
 type Runner struct {
@@ -319,6 +351,7 @@ type Runner struct {
 	GenericEntry  bool     `koanf:"generic_entry"`
 }
 
+// Plugin is declared in the config's plugins array or by a manifest in PluginsDir, see LoadPlugins.
 type Plugin struct {
 	GeneralModule    `koanf:",squash"`
 	Cmd              string            `koanf:"cmd"`
@@ -336,6 +369,30 @@ type Plugin struct {
 	KvSeparator      string            `koanf:"kv_separator"`
 	Output           bool              `koanf:"output"`
 	Keywords         []string          `koanf:"keywords"`
+
+	// Kind is "rpc" for plugins that keep running or "rofi" for rofi script modes, empty for regular plugins.
+	Kind string `koanf:"kind"`
+
+	// Disabled leaves the plugin out, f.e. one found in PluginsDir.
+	Disabled bool `koanf:"disabled"`
+
+	// Actions are added to every entry of the plugin.
+	Actions []util.Action `koanf:"actions"`
+
+	// Columns maps the columns of csv and tsv output to entry fields.
+	Columns []string `koanf:"columns"`
+
+	// Header skips the first row of csv and tsv output, it's used as mapping if Columns isn't set.
+	Header bool `koanf:"header"`
+
+	// CacheTTL is the time in seconds the output of Src is reused for, 0 disables caching.
+	CacheTTL int `koanf:"cache_ttl"`
+
+	// CacheByTerm keeps the cached output per term, for sources that read the term from stdin.
+	CacheByTerm bool `koanf:"cache_by_term"`
+
+	// internal
+	Dir string `koanf:"-"`
 }
 
 type Search struct {
@@ -400,6 +457,8 @@ func Get(config string) error {
 
 	Cfg = parsed
 
+	LoadPlugins()
+
 	setTerminal()
 
 	if Cfg.Terminal == "" {
@@ -413,6 +472,19 @@ func Get(config string) error {
 	return marshallErr
 }
 
+// Default uses the embedded default config, f.e. if the config file can't be written.
+func Default() {
+	defaults := koanf.New(".")
+	_ = defaults.Load(rawbytes.Provider(defaultConfig), toml.Parser())
+
+	Cfg = &Config{}
+	_ = defaults.Unmarshal("", Cfg)
+
+	LoadPlugins()
+
+	setTerminal()
+}
+
 func setTerminal() {
 	if Cfg.Terminal != "" {
 		path, _ := exec.LookPath(Cfg.Terminal)
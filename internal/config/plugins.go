@@ -0,0 +1,107 @@
+package config
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/abenz1267/walker/internal/util"
+	"github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/toml/v2"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+)
+
+// manifests are the file names a plugin's manifest can have, checked in this order.
+var manifests = []struct {
+	name   string
+	parser koanf.Parser
+}{
+	{"manifest.toml", toml.Parser()},
+	{"manifest.json", json.Parser()},
+	{"manifest.yaml", yaml.Parser()},
+}
+
+// PluginsDir holds one folder per plugin, containing its manifest and scripts.
+func PluginsDir() string {
+	return filepath.Join(util.ConfigDir(), "plugins")
+}
+
+// LoadPlugins adds the plugins found in PluginsDir to the ones declared in the config, replacing the previously found ones.
+// Plugins declared in the config take precedence over folders with the same name, disabled plugins are skipped.
+func LoadPlugins() {
+	Cfg.Plugins = slices.DeleteFunc(Cfg.Plugins, func(p Plugin) bool {
+		return p.Dir != ""
+	})
+
+	dirs, err := os.ReadDir(PluginsDir())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println(err)
+		}
+
+		return
+	}
+
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+
+		dir := filepath.Join(PluginsDir(), d.Name())
+
+		p, ok := loadManifest(dir)
+		if !ok || p.Disabled {
+			continue
+		}
+
+		if p.Name == "" {
+			p.Name = d.Name()
+		}
+
+		exists := slices.ContainsFunc(Cfg.Plugins, func(c Plugin) bool {
+			return c.Name == p.Name
+		})
+
+		if exists {
+			log.Printf("plugin %s in %s is already declared in the config\n", p.Name, dir)
+			continue
+		}
+
+		Cfg.Plugins = append(Cfg.Plugins, p)
+	}
+}
+
+func loadManifest(dir string) (Plugin, bool) {
+	p := Plugin{}
+
+	for _, m := range manifests {
+		path := filepath.Join(dir, m.name)
+
+		if !util.FileExists(path) {
+			continue
+		}
+
+		k := koanf.New(".")
+
+		err := k.Load(file.Provider(path), m.parser)
+		if err != nil {
+			log.Printf("plugin manifest %s: %s\n", path, err)
+			return p, false
+		}
+
+		err = k.Unmarshal("", &p)
+		if err != nil {
+			log.Printf("plugin manifest %s: %s\n", path, err)
+			return p, false
+		}
+
+		p.Dir = dir
+
+		return p, true
+	}
+
+	return p, false
+}
@@ -0,0 +1,451 @@
+// Package engine queries modules and ranks their entries independent of the window. It doesn't use GTK itself, but
+// config and modules still import it, so building the package and its tests needs the GTK libraries.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/history"
+	"github.com/abenz1267/walker/internal/modules"
+	"github.com/abenz1267/walker/internal/modules/windows/wlr"
+	"github.com/abenz1267/walker/internal/util"
+)
+
+// Query describes a single search over a set of modules.
+type Query struct {
+	Text     string
+	Modules  []modules.Workable
+	KeepSort bool
+
+	// Single is the name of the module that is used exclusively, if any.
+	Single string
+
+	// IsDmenu restricts matching to the label.
+	IsDmenu bool
+
+	// Accepted is the identifier of an entry that should always be sorted on top, f.e. an accepted typeahead suggestion.
+	Accepted string
+
+	// MarkerColor enables highlighting of matched characters in MatchedLabel and MatchedSub.
+	MarkerColor string
+
+	History history.History
+
+	// Context is where and when the query happens, used to boost entries used in a similar context.
+	Context history.Use
+
+	Debug bool
+}
+
+// CurrentContext returns the time, the focused app and the working directory. The focused app is only known while the window manager connection is running.
+func CurrentContext() history.Use {
+	cwd, _ := os.Getwd()
+
+	return history.Use{
+		Time:  time.Now(),
+		AppId: wlr.Focused(),
+		Cwd:   cwd,
+	}
+}
+
+// Batch is the state of a streamed query: the ranked entries so far, the names of the modules still loading and the
+// errors of the modules that failed.
+type Batch struct {
+	Entries []util.Entry
+	Pending []string
+	Errors  map[string]error
+}
+
+// Run queries all modules that are applicable to the query text and returns the filtered and ranked entries.
+// Nothing is returned if ctx is done before all modules finished.
+func Run(ctx context.Context, q Query) []util.Entry {
+	var entries []util.Entry
+
+	q.stream(ctx, false, func(b Batch) {
+		entries = b.Entries
+	})
+
+	return entries
+}
+
+// Stream queries the modules like Run, but calls fn with the entries ranked so far whenever a module delivered entries or finished.
+// The first call only lists the pending modules. fn is called from a single goroutine, the last call has no pending modules and Stream returns after it.
+// Once ctx is done the modules are cancelled and Stream returns without calling fn again.
+func Stream(ctx context.Context, q Query, fn func(Batch)) {
+	q.stream(ctx, true, fn)
+}
+
+type moduleResult struct {
+	module         string
+	entries        []util.Entry
+	hasEntryPrefix bool
+	err            error
+	done           bool
+}
+
+func (q Query) stream(ctx context.Context, incremental bool, fn func(Batch)) {
+	text := q.Text
+	p := q.Modules
+
+	hasPrefix := HasPrefix(text, p)
+
+	results := make(chan moduleResult)
+	pending := []string{}
+
+	for k := range p {
+		if !Applicable(text, p[k], len(p), hasPrefix) {
+			continue
+		}
+
+		if !p[k].General().IsSetup {
+			p[k].SetupData()
+		}
+
+		pending = append(pending, p[k].General().Name)
+
+		go func(text string, w modules.Workable) {
+			g := w.General()
+
+			// the module's context can time out on its own, its late results are dropped then
+			mctx := ctx
+
+			if g.Timeout > 0 {
+				var cancel context.CancelFunc
+
+				mctx, cancel = context.WithTimeout(ctx, time.Duration(g.Timeout)*time.Millisecond)
+				defer cancel()
+			}
+
+			send := func(res moduleResult) {
+				select {
+				case results <- res:
+				case <-mctx.Done():
+				}
+			}
+
+			finished := make(chan struct{})
+
+			go func() {
+				defer close(finished)
+
+				minChars := g.MinChars
+
+				if m, ok := w.(modules.MinQuery); ok {
+					minChars = max(minChars, m.MinQueryLength())
+				}
+
+				if len(text) < minChars {
+					return
+				}
+
+				text = strings.TrimPrefix(text, g.Prefix)
+
+				var learned map[string]float64
+
+				if g.History {
+					learned = history.Learned(history.GetInputHistory(g.Name), q.Text, g.Frecency.HalfLife)
+				}
+
+				push := func(e []util.Entry) {
+					entries, hasEntryPrefix := q.rank(text, w, e, learned)
+					send(moduleResult{module: g.Name, entries: entries, hasEntryPrefix: hasEntryPrefix})
+				}
+
+				switch m := w.(type) {
+				case modules.Reporting:
+					err := m.QueryStream(mctx, text, push)
+
+					// cancelled and timed out queries aren't failures of the module
+					if err != nil && mctx.Err() == nil {
+						send(moduleResult{module: g.Name, err: err})
+					}
+				case modules.Streaming:
+					m.StreamEntries(mctx, text, push)
+				default:
+					push(w.Entries(mctx, text))
+				}
+			}()
+
+			// a timed out module counts as done, even if it doesn't return yet
+			select {
+			case <-finished:
+			case <-mctx.Done():
+			}
+
+			select {
+			case results <- moduleResult{module: g.Name, done: true}:
+			case <-ctx.Done():
+			}
+		}(text, p[k])
+	}
+
+	entries := []util.Entry{}
+	hasEntryPrefix := false
+	errs := make(map[string]error)
+
+	if len(pending) == 0 {
+		fn(Batch{Entries: q.finish(entries, false, true)})
+		return
+	}
+
+	if incremental {
+		fn(Batch{Pending: slices.Clone(pending)})
+	}
+
+	for len(pending) > 0 {
+		var res moduleResult
+
+		select {
+		case res = <-results:
+		case <-ctx.Done():
+			return
+		}
+
+		// results that arrive after the module timed out are dropped
+		if !slices.Contains(pending, res.module) {
+			continue
+		}
+
+		switch {
+		case res.done:
+			if i := slices.Index(pending, res.module); i != -1 {
+				pending = slices.Delete(pending, i, i+1)
+			}
+		case res.err != nil:
+			errs[res.module] = res.err
+		default:
+			entries = append(entries, res.entries...)
+			hasEntryPrefix = hasEntryPrefix || res.hasEntryPrefix
+		}
+
+		if !incremental && len(pending) > 0 {
+			continue
+		}
+
+		fn(Batch{
+			Entries: q.finish(slices.Clone(entries), hasEntryPrefix, len(pending) == 0),
+			Pending: slices.Clone(pending),
+			Errors:  maps.Clone(errs),
+		})
+	}
+
+	// the renames registered while ranking are persisted once the query is done
+	history.SaveRenames()
+}
+
+// rank scores the entries of a module and drops the ones that don't match.
+func (q Query) rank(text string, w modules.Workable, e []util.Entry, learned map[string]float64) ([]util.Entry, bool) {
+	g := w.General()
+
+	toPush := []util.Entry{}
+	hasEntryPrefix := false
+
+	for k := range e {
+		if Blacklisted(g.Blacklist, e[k]) {
+			continue
+		}
+
+		if e[k].SingleModuleOnly && q.Single == "" {
+			continue
+		}
+
+		e[k].Module = g.Name
+		e[k].Weight = g.Weight
+
+		if e[k].ID != "" {
+			history.Rename(e[k].ID, e[k].LegacyIdentifier)
+		}
+
+		toMatch := text
+
+		if e[k].MatchFields > 0 {
+			textFields := strings.Fields(text)
+
+			if len(textFields) > 0 {
+				toMatch = strings.Join(textFields[:1], " ")
+			}
+		}
+
+		if e[k].RecalculateScore {
+			e[k].ScoreFinal = 0
+			e[k].ScoreFuzzy = 0
+		}
+
+		if e[k].ScoreFinal == 0 {
+			switch e[k].Matching {
+			case util.AlwaysTopOnEmptySearch:
+				if text != "" {
+					e[k].ScoreFinal = q.score(&e[k], toMatch, g, learned)
+				} else {
+					e[k].ScoreFinal = 1000
+				}
+			case util.Fuzzy:
+				e[k].ScoreFinal = q.score(&e[k], toMatch, g, learned)
+			case util.AlwaysTop:
+				if e[k].ScoreFinal == 0 {
+					e[k].ScoreFinal = 1000
+				}
+			case util.AlwaysBottom:
+				if e[k].ScoreFinal == 0 {
+					e[k].ScoreFinal = 1
+				}
+			default:
+				e[k].ScoreFinal = 0
+			}
+		}
+
+		if toMatch == "" {
+			if e[k].ScoreFinal == 0 {
+				continue
+			}
+		} else if e[k].ScoreFinal <= float64(config.Cfg.List.VisibilityThreshold) {
+			continue
+		}
+
+		if e[k].Prefix != "" && strings.HasPrefix(text, e[k].Prefix) {
+			hasEntryPrefix = true
+		} else if e[k].IgnoreUnprefixed {
+			continue
+		}
+
+		toPush = append(toPush, e[k])
+	}
+
+	return toPush, hasEntryPrefix
+}
+
+// finish applies the entry prefixes, sorts and truncates the collected entries.
+func (q Query) finish(entries []util.Entry, hasEntryPrefix bool, final bool) []util.Entry {
+	text := q.Text
+
+	if hasEntryPrefix {
+		finalEntries := []util.Entry{}
+
+		for _, v := range entries {
+			if v.Prefix != "" {
+				finalEntries = append(finalEntries, v)
+			}
+		}
+
+		entries = finalEntries
+	}
+
+	if !q.KeepSort || text != "" {
+		Sort(entries, text, q.KeepSort, q.Accepted)
+	}
+
+	if len(entries) > config.Cfg.List.MaxEntries {
+		entries = entries[:config.Cfg.List.MaxEntries]
+	}
+
+	if q.Debug && final {
+		for _, v := range entries {
+			fmt.Printf("Entries == label: %s sub: %s score: %f\n", v.Label, v.Sub, v.ScoreFinal)
+		}
+	}
+
+	return entries
+}
+
+// HasPrefix reports whether text starts with the prefix of any of the modules.
+func HasPrefix(text string, p []modules.Workable) bool {
+	for _, v := range p {
+		prefix := v.General().Prefix
+
+		if len(prefix) > 0 && strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Applicable decides if a module takes part in a query, based on its prefix and whether it's switcher-only.
+// Prefix routing only applies if more than one module is queried.
+func Applicable(text string, w modules.Workable, amount int, hasPrefix bool) bool {
+	if w == nil {
+		return false
+	}
+
+	if amount < 2 {
+		return true
+	}
+
+	prefix := w.General().Prefix
+
+	if w.General().SwitcherOnly {
+		if prefix == "" {
+			return false
+		}
+
+		if !strings.HasPrefix(text, prefix) {
+			return false
+		}
+	}
+
+	if hasPrefix && prefix == "" {
+		return false
+	}
+
+	if !hasPrefix && prefix != "" {
+		return false
+	}
+
+	if hasPrefix && !strings.HasPrefix(text, prefix) {
+		return false
+	}
+
+	return true
+}
+
+// Blacklisted reports whether the entry matches any of the blacklist rules.
+func Blacklisted(blacklist []config.Blacklist, entry util.Entry) bool {
+	for _, b := range blacklist {
+		if b.Reg == nil {
+			continue
+		}
+
+		if !b.Label && !b.Sub {
+			if b.Reg.MatchString(entry.Label) {
+				return true
+			}
+
+			if b.Reg.MatchString(entry.Sub) {
+				return true
+			}
+		}
+
+		if b.Label {
+			if b.Reg.MatchString(entry.Label) {
+				return true
+			}
+		}
+
+		if b.Sub {
+			if b.Reg.MatchString(entry.Sub) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// PrepareBlacklists compiles the blacklist expressions of the given modules.
+func PrepareBlacklists(p []modules.Workable) {
+	for _, v := range p {
+		c := v.General()
+
+		for n, b := range c.Blacklist {
+			c.Blacklist[n].Reg = regexp.MustCompile(b.Regexp)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"log"
+	"os"
+	"slices"
+
+	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/history"
+	"github.com/abenz1267/walker/internal/modules"
+	"github.com/abenz1267/walker/internal/modules/emojis"
+	"github.com/abenz1267/walker/internal/modules/symbols"
+	"github.com/abenz1267/walker/internal/modules/windows"
+)
+
+// Available creates all builtin modules and plugins and returns the ones that are set up and not disabled.
+// The dmenu module is only included if withDmenu is set, as it reads from stdin.
+func Available(hstry history.History, markerColor string, withDmenu bool) []modules.Workable {
+	res := []modules.Workable{
+		&modules.Applications{Hstry: hstry},
+		&modules.Bookmarks{},
+		&modules.AI{},
+		&modules.Runner{},
+		&modules.Websearch{},
+		modules.Adapt(&modules.Calc{}),
+		&modules.Commands{},
+		&modules.SSH{},
+		&modules.Finder{MarkerColor: markerColor},
+		&modules.Switcher{},
+		&emojis.Emojis{},
+		&symbols.Symbols{},
+		&modules.CustomCommands{},
+		&windows.Windows{},
+	}
+
+	if os.Getenv("XDG_CURRENT_DESKTOP") == "Hyprland" {
+		res = append(res, &modules.XdphPicker{})
+	}
+
+	if withDmenu {
+		res = append(res, &modules.Dmenu{})
+	}
+
+	config.Cfg.Hidden = []string{}
+
+	return append(setup(res), plugins()...)
+}
+
+// plugins creates the modules of the configured plugins and returns the ones that are set up and not disabled.
+func plugins() []modules.Workable {
+	res := []modules.Workable{}
+
+	for _, v := range config.Cfg.Plugins {
+		if v.Disabled {
+			continue
+		}
+
+		switch v.Kind {
+		case modules.PluginKindRPC:
+			res = append(res, &modules.RPCPlugin{Config: v})
+			continue
+		case modules.PluginKindRofi:
+			res = append(res, &modules.RofiPlugin{Config: v})
+			continue
+		}
+
+		e := &modules.Plugin{}
+		e.Config = v
+
+		res = append(res, e)
+	}
+
+	return setup(res)
+}
+
+// ReloadPlugins replaces the plugins in p with the ones of the current config and stops running rpc plugins. The other
+// modules are kept as they are, so they aren't set up twice.
+func ReloadPlugins(p []modules.Workable) []modules.Workable {
+	res := []modules.Workable{}
+
+	config.Cfg.Available = []string{}
+	config.Cfg.Hidden = []string{}
+
+	for _, v := range p {
+		switch m := v.(type) {
+		case *modules.RPCPlugin:
+			m.Stop()
+			continue
+		case *modules.Plugin, *modules.RofiPlugin:
+			continue
+		}
+
+		res = append(res, v)
+		register(v)
+	}
+
+	return append(res, plugins()...)
+}
+
+// setup returns the modules that are set up and not disabled and registers them.
+func setup(res []modules.Workable) []modules.Workable {
+	available := []modules.Workable{}
+
+	for _, v := range res {
+		if v == nil {
+			continue
+		}
+
+		if ok := v.Setup(); ok {
+			if v.General().Name == "" {
+				log.Panicln("module has no name\n")
+			}
+
+			if slices.Contains(config.Cfg.Disabled, v.General().Name) {
+				continue
+			}
+
+			available = append(available, v)
+			register(v)
+		}
+	}
+
+	return available
+}
+
+// register adds the module to the available and hidden modules of the config.
+func register(v modules.Workable) {
+	config.Cfg.Available = append(config.Cfg.Available, v.General().Name)
+
+	if v.General().Hidden {
+		config.Cfg.Hidden = append(config.Cfg.Hidden, v.General().Name)
+	}
+}
+
+// Find returns the module with the given name.
+func Find(name string, p []modules.Workable) modules.Workable {
+	for _, v := range p {
+		if v != nil && v.General().Name == name {
+			return v
+		}
+	}
+
+	return nil
+}
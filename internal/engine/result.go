@@ -0,0 +1,46 @@
+package engine
+
+import "github.com/abenz1267/walker/internal/util"
+
+// Result is the serializable form of a ranked entry.
+type Result struct {
+	Identifier string  `json:"identifier"`
+	Label      string  `json:"label"`
+	Sub        string  `json:"sub,omitempty"`
+	Exec       string  `json:"exec,omitempty"`
+	ExecAlt    string  `json:"exec_alt,omitempty"`
+	Icon       string  `json:"icon,omitempty"`
+	Value      string  `json:"value,omitempty"`
+	Module     string  `json:"module"`
+	Score      float64 `json:"score"`
+
+	Actions []ResultAction `json:"actions,omitempty"`
+}
+
+// ResultAction names an action that can be run on the result via the control socket.
+type ResultAction struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Key   string `json:"key,omitempty"`
+}
+
+func ToResult(entry util.Entry) Result {
+	actions := []ResultAction{}
+
+	for _, v := range entry.AllActions() {
+		actions = append(actions, ResultAction{Name: v.Name, Label: v.Label, Key: v.Key})
+	}
+
+	return Result{
+		Identifier: entry.Identifier(),
+		Label:      entry.Label,
+		Sub:        entry.Sub,
+		Exec:       entry.Exec,
+		ExecAlt:    entry.ExecAlt,
+		Icon:       entry.Icon,
+		Value:      entry.Value,
+		Module:     entry.Module,
+		Score:      entry.ScoreFinal,
+		Actions:    actions,
+	}
+}
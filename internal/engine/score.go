@@ -0,0 +1,275 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/history"
+	"github.com/abenz1267/walker/internal/util"
+)
+
+// fields are the names of the matchable fields of an entry, in the order they are matched. All categories share the last one.
+var fields = []string{"label", "sub", "searchable", "searchable2", "categories"}
+
+// defaultFieldWeights lowers the weight of a field by 0.1 per position, down to 0.7.
+var defaultFieldWeights = map[string]float64{
+	"label":       1,
+	"sub":         0.9,
+	"searchable":  0.8,
+	"searchable2": 0.7,
+	"categories":  0.7,
+}
+
+// fieldWeight returns the configured weight of the k-th matchable field. A weight of 0 excludes the field.
+func fieldWeight(weights map[string]float64, k int) float64 {
+	name := fields[min(k, len(fields)-1)]
+
+	if val, ok := weights[name]; ok {
+		return val
+	}
+
+	return defaultFieldWeights[name]
+}
+
+// score ranks the entry by its fuzzy match, its usage and how often it was chosen for this query (learned).
+func (q Query) score(entry *util.Entry, text string, g *config.GeneralModule, learned map[string]float64) float64 {
+	textLength := len(text)
+
+	entry.MatchedLabel = ""
+	entry.MatchedSub = ""
+
+	if entry.Prefix != "" {
+		if strings.HasPrefix(text, entry.Prefix) {
+			text = strings.TrimPrefix(text, entry.Prefix)
+		}
+	}
+
+	if textLength == 0 {
+		return 1
+	}
+
+	var matchables []string
+
+	if !q.IsDmenu {
+		matchables = []string{entry.Label, entry.Sub, entry.Searchable, entry.Searchable2}
+		matchables = append(matchables, entry.Categories...)
+	} else {
+		// the sub is skipped, it's "Dmenu" unless given
+		matchables = []string{entry.Label, "", entry.Searchable}
+	}
+
+	m := 0.0
+
+	if g.Matcher == util.MatcherTokens {
+		// the terms have to match the entry as a whole, not each field on its own
+		m = q.matchTokens(entry, text, matchables, g)
+	} else {
+		var pos *[]int
+
+		for k, t := range matchables {
+			if t == "" {
+				continue
+			}
+
+			weight := fieldWeight(g.FieldWeights, k)
+
+			if weight <= 0 {
+				continue
+			}
+
+			remember := ""
+
+			if k == 0 && q.Single != "" && q.Single == config.Cfg.Builtins.Emojis.Name {
+				remember = strings.Fields(t)[0]
+				t = entry.Searchable
+			}
+
+			var score float64
+
+			if strings.HasPrefix(text, "'") {
+				cleanText := strings.TrimPrefix(text, "'")
+
+				score, _ = util.ExactScore(cleanText, t)
+
+				f := strings.Index(strings.ToLower(t), strings.ToLower(cleanText))
+
+				if f != -1 {
+					poss := []int{}
+
+					for i := f; i < f+len(text); i++ {
+						poss = append(poss, i)
+					}
+
+					pos = &poss
+				}
+			} else {
+				score, pos = util.Match(g.Matcher, text, t)
+			}
+
+			if score < 2 {
+				continue
+			}
+
+			score = score * weight
+
+			if score > entry.ScoreFuzzy {
+				m = weight
+
+				if config.Cfg.List.DynamicSub && k > 1 {
+					entry.MatchedSub = t
+				}
+
+				if q.MarkerColor != "" {
+					res := ""
+
+					if pos != nil {
+						res = highlight(t, *pos, q.MarkerColor)
+					}
+
+					if remember != "" {
+						res = fmt.Sprintf("%s %s", remember, res)
+					}
+
+					if k == 0 {
+						entry.MatchedLabel = res
+					} else if k > 0 {
+						entry.MatchedSub = res
+					}
+				}
+
+				entry.ScoreFuzzy = score
+			}
+		}
+	}
+
+	if entry.ScoreFuzzy == 0 {
+		return 0
+	}
+
+	usageScore := 0.0
+
+	if g.History {
+		usageScore = Usage(entry, q.History, text, g.Frecency, q.Context)
+		usageScore += learned[entry.Identifier()]
+	}
+
+	if textLength == 0 {
+		textLength = 1
+	}
+
+	tm := 1.0 / float64(textLength)
+
+	score := usageScore*tm + float64(entry.ScoreFuzzy)/tm
+
+	if q.Debug {
+		fmt.Printf("Matching == label: %s sub: %s searchable: %s categories: %s score: %f usage: %f fuzzy: %f m: %f\n", entry.Label, entry.Sub, entry.Searchable, entry.Categories, score, usageScore, entry.ScoreFuzzy, m)
+	}
+
+	return score
+}
+
+// matchTokens scores the entry with fzf's extended search syntax against all its matchable fields at once and
+// highlights the matched fields. It returns 1 if the entry matched.
+func (q Query) matchTokens(entry *util.Entry, text string, matchables []string, g *config.GeneralModule) float64 {
+	weights := []float64{}
+
+	for k := range matchables {
+		weights = append(weights, fieldWeight(g.FieldWeights, k))
+	}
+
+	score, pos := util.TokensScoreFields(text, matchables, weights)
+	if score < 2 {
+		return 0
+	}
+
+	entry.ScoreFuzzy = score
+
+	for k, t := range matchables {
+		if len(pos[k]) == 0 {
+			continue
+		}
+
+		res := t
+
+		if q.MarkerColor != "" {
+			res = highlight(t, pos[k], q.MarkerColor)
+		}
+
+		switch {
+		case k == 0 && q.MarkerColor != "":
+			entry.MatchedLabel = res
+		case k == 1 && q.MarkerColor != "":
+			entry.MatchedSub = res
+		case k > 1 && entry.MatchedSub == "" && (config.Cfg.List.DynamicSub || q.MarkerColor != ""):
+			entry.MatchedSub = res
+		}
+	}
+
+	return 1
+}
+
+// highlight wraps the characters of t at the given positions in spans of the given color.
+func highlight(t string, pos []int, color string) string {
+	res := ""
+
+	for k, v := range t {
+		if slices.Contains(pos, k) {
+			res = fmt.Sprintf("%s<span color=\"%s\">%s</span>", res, color, string(v))
+		} else {
+			res = fmt.Sprintf("%s%s", res, string(v))
+		}
+	}
+
+	return res
+}
+
+// Usage looks up the history of the entry for all queries starting with text and scores it with the given frecency model.
+// If enabled, uses in a context similar to c add to the score.
+func Usage(entry *util.Entry, hstry history.History, text string, f config.Frecency, c history.Use) float64 {
+	identifier := entry.Identifier()
+	legacy := ""
+	score := 0.0
+
+	for k, v := range hstry {
+		if !strings.HasPrefix(k, text) {
+			continue
+		}
+
+		val, ok := v[identifier]
+
+		// records made before the entry had an id are moved once the history is migrated, until then they still count
+		if !ok && entry.ID != "" {
+			if legacy == "" {
+				legacy = entry.LegacyIdentifier()
+			}
+
+			val, ok = v[legacy]
+		}
+
+		if !ok {
+			continue
+		}
+
+		if entry.LastUsed.IsZero() || val.LastUsed.After(entry.LastUsed) {
+			entry.Used = val.Used
+			entry.DaysSinceUsed = val.DaysSinceUsed
+			entry.LastUsed = val.LastUsed
+
+			if f.Model == history.FrecencyCount {
+				score = val.Frecency(f.Model, f.HalfLife)
+			}
+		}
+
+		if f.Model != history.FrecencyCount {
+			score += val.Frecency(f.Model, f.HalfLife)
+		}
+
+		if f.Context {
+			score += val.Context(c, f.HalfLife)
+		}
+	}
+
+	return score
+}
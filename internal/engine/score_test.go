@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/history"
+	"github.com/abenz1267/walker/internal/util"
+	"github.com/junegunn/fzf/src/algo"
+)
+
+func init() {
+	algo.Init("default")
+	config.Cfg = &config.Config{}
+}
+
+func TestScoreTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		match bool
+	}{
+		{"terms in label and sub", "fire brow", true},
+		{"inverse term matching the sub", "fire !brow", false},
+		{"inverse term matching nothing", "fire !chrome", true},
+	}
+
+	g := &config.GeneralModule{Matcher: util.MatcherTokens}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := util.Entry{Label: "Firefox", Sub: "Web Browser"}
+
+			if got := (Query{}).score(&entry, tt.text, g, nil) > 0; got != tt.match {
+				t.Errorf("got match %v, want %v", got, tt.match)
+			}
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	now := time.Now()
+
+	older := &history.HistoryEntry{LastUsed: now.Add(-72 * time.Hour), Used: 2, DaysSinceUsed: 3, Uses: []history.Use{{Time: now.Add(-96 * time.Hour)}, {Time: now.Add(-72 * time.Hour)}}}
+	recent := &history.HistoryEntry{LastUsed: now.Add(-24 * time.Hour), Used: 1, DaysSinceUsed: 1, Uses: []history.Use{{Time: now.Add(-24 * time.Hour)}}}
+	other := &history.HistoryEntry{LastUsed: now, Used: 5, Uses: []history.Use{{Time: now}}}
+
+	entry := util.Entry{Label: "firefox", ID: "firefox.desktop"}
+
+	hstry := history.History{
+		"f":    {entry.ID: older},
+		"fire": {entry.ID: recent},
+		"x":    {entry.ID: other},
+	}
+
+	legacy := history.History{
+		"f": {entry.LegacyIdentifier(): older},
+	}
+
+	tests := []struct {
+		name  string
+		hstry history.History
+		text  string
+		model string
+		want  float64
+	}{
+		{"count uses the most recent", hstry, "f", history.FrecencyCount, recent.Frecency(history.FrecencyCount, 0)},
+		{"count prefix", hstry, "fire", history.FrecencyCount, recent.Frecency(history.FrecencyCount, 0)},
+		{"buckets", hstry, "f", history.FrecencyBuckets, older.Frecency(history.FrecencyBuckets, 0) + recent.Frecency(history.FrecencyBuckets, 0)},
+		{"decay", hstry, "f", history.FrecencyDecay, older.Frecency(history.FrecencyDecay, 0) + recent.Frecency(history.FrecencyDecay, 0)},
+		{"no match", hstry, "g", history.FrecencyDecay, 0},
+		{"legacy identifier", legacy, "f", history.FrecencyDecay, older.Frecency(history.FrecencyDecay, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := entry
+
+			got := Usage(&e, tt.hstry, tt.text, config.Frecency{Model: tt.model}, history.Use{})
+
+			if math.Abs(got-tt.want) > 0.001 {
+				t.Errorf("got %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreLearned(t *testing.T) {
+	g := &config.GeneralModule{History: true}
+	text := "fire"
+
+	plain := util.Entry{Label: "firefox"}
+	boosted := plain
+
+	base := Query{}.score(&plain, text, g, nil)
+	got := Query{}.score(&boosted, text, g, map[string]float64{boosted.Identifier(): 8})
+
+	// usage is divided by the length of the query
+	if want := base + 8.0/float64(len(text)); math.Abs(got-want) > 0.001 {
+		t.Errorf("got %f, want %f", got, want)
+	}
+}
+
+func TestScoreFieldWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   util.Entry
+		weights map[string]float64
+		dmenu   bool
+		field   string
+		want    float64
+	}{
+		{"label", util.Entry{Label: "firefox"}, nil, false, "firefox", 1},
+		{"searchable default", util.Entry{Label: "browser", Searchable: "firefox"}, nil, false, "firefox", 0.8},
+		{"searchable2 default", util.Entry{Label: "browser", Searchable2: "firefox"}, nil, false, "firefox", 0.7},
+		{"category default", util.Entry{Label: "browser", Categories: []string{"web", "firefox"}}, nil, false, "firefox", 0.7},
+		{"configured", util.Entry{Label: "browser", Searchable: "firefox"}, map[string]float64{"searchable": 0.1}, false, "firefox", 0.1},
+		{"excluded", util.Entry{Label: "browser", Searchable: "firefox"}, map[string]float64{"searchable": 0}, false, "", 0},
+		{"best field wins", util.Entry{Label: "firefox", Sub: "firefox"}, map[string]float64{"label": 0.5}, false, "firefox", 0.9},
+		{"dmenu skips sub", util.Entry{Label: "browser", Sub: "firefox"}, nil, true, "", 0},
+		{"dmenu searchable", util.Entry{Label: "browser", Searchable: "firefox"}, nil, true, "firefox", 0.8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := Query{IsDmenu: tt.dmenu}
+			g := &config.GeneralModule{FieldWeights: tt.weights}
+
+			entry := tt.entry
+			q.score(&entry, "firefox", g, nil)
+
+			want := 0.0
+
+			if tt.field != "" {
+				raw, _ := util.FuzzyScore("firefox", tt.field)
+				want = raw * tt.want
+			}
+
+			if math.Abs(entry.ScoreFuzzy-want) > 0.001 {
+				t.Errorf("got fuzzy score %f, want %f", entry.ScoreFuzzy, want)
+			}
+		})
+	}
+}
@@ -1,4 +1,4 @@
-package ui
+package engine
 
 import (
 	"slices"
@@ -7,20 +7,20 @@ import (
 	"github.com/abenz1267/walker/internal/util"
 )
 
-func sortEntries(entries []util.Entry, keepSort bool) {
+// Sort orders entries by score, matching type, module weight and usage.
+// The entry identified by accepted is always put first.
+func Sort(entries []util.Entry, text string, keepSort bool, accepted string) {
 	slices.SortFunc(entries, func(a, b util.Entry) int {
-		if tahAcceptedIdentifier != "" {
-			if a.Identifier() == tahAcceptedIdentifier {
+		if accepted != "" {
+			if a.Identifier() == accepted {
 				return -1
 			}
 
-			if b.Identifier() == tahAcceptedIdentifier {
+			if b.Identifier() == accepted {
 				return 1
 			}
 		}
 
-		text := elements.input.Text()
-
 		if text == "" {
 			if a.Matching == util.AlwaysTopOnEmptySearch && b.Matching != util.AlwaysTopOnEmptySearch {
 				return -1
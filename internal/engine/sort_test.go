@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abenz1267/walker/internal/util"
+)
+
+func TestSort(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		entries  []util.Entry
+		text     string
+		accepted string
+		want     []string
+	}{
+		{
+			name: "score",
+			text: "a",
+			entries: []util.Entry{
+				{Label: "low", ScoreFinal: 10},
+				{Label: "high", ScoreFinal: 200},
+			},
+			want: []string{"high", "low"},
+		},
+		{
+			name: "always top and bottom",
+			text: "a",
+			entries: []util.Entry{
+				{Label: "bottom", ScoreFinal: 500, Matching: util.AlwaysBottom},
+				{Label: "fuzzy", ScoreFinal: 100},
+				{Label: "top", ScoreFinal: 1, Matching: util.AlwaysTop},
+			},
+			want: []string{"top", "fuzzy", "bottom"},
+		},
+		{
+			name: "top on empty search",
+			entries: []util.Entry{
+				{Label: "fuzzy", ScoreFinal: 100},
+				{Label: "top", ScoreFinal: 1, Matching: util.AlwaysTopOnEmptySearch},
+			},
+			want: []string{"top", "fuzzy"},
+		},
+		{
+			name:     "accepted",
+			text:     "a",
+			accepted: "accepted",
+			entries: []util.Entry{
+				{Label: "top", Matching: util.AlwaysTop},
+				{Label: "other", ID: "accepted", ScoreFinal: 1},
+			},
+			want: []string{"other", "top"},
+		},
+		{
+			name: "module weight for close scores",
+			text: "a",
+			entries: []util.Entry{
+				{Label: "light", Module: "a", Weight: 1, ScoreFinal: 110},
+				{Label: "heavy", Module: "b", Weight: 5, ScoreFinal: 100},
+			},
+			want: []string{"heavy", "light"},
+		},
+		{
+			name: "equal scores by last use",
+			text: "a",
+			entries: []util.Entry{
+				{Label: "a", ScoreFinal: 100, LastUsed: now.Add(-time.Hour)},
+				{Label: "b", ScoreFinal: 100, LastUsed: now},
+			},
+			want: []string{"b", "a"},
+		},
+		{
+			name: "equal scores by label",
+			text: "a",
+			entries: []util.Entry{
+				{Label: "b", ScoreFinal: 100},
+				{Label: "a", ScoreFinal: 100},
+			},
+			want: []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Sort(tt.entries, tt.text, false, tt.accepted)
+
+			got := []string{}
+
+			for _, v := range tt.entries {
+				got = append(got, v.Label)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/abenz1267/walker/internal/store"
+)
+
+const exportVersion = 2
+
+// Export is the portable JSON representation of the usage and input history.
+type Export struct {
+	Version      int          `json:"version"`
+	History      History      `json:"history"`
+	InputHistory InputHistory `json:"input_history"`
+}
+
+// ExportJSON writes the stored usage and input history as JSON.
+func ExportJSON(w io.Writer) error {
+	inputhstry := InputHistory{}
+	_ = store.Load(InputHistoryBucket, &inputhstry)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(Export{
+		Version:      exportVersion,
+		History:      Get(),
+		InputHistory: inputhstry,
+	})
+}
+
+// ReadExport reads an export written by ExportJSON.
+func ReadExport(r io.Reader) (Export, error) {
+	var in Export
+
+	err := json.NewDecoder(r).Decode(&in)
+	if err != nil {
+		return in, err
+	}
+
+	if in.Version > exportVersion {
+		return in, fmt.Errorf("unsupported export version %d", in.Version)
+	}
+
+	return in, nil
+}
+
+// Import merges an export into hstry and the input history and saves both. A running service has to import it
+// itself, otherwise it overwrites the import with its own history.
+// For entries known on both sides the higher usage count and the most recent usage win, recorded uses are combined.
+// Input history items that already exist are skipped.
+func Import(hstry History, in Export) {
+	hstry.Merge(in.History)
+	store.Save(HistoryBucket, hstry)
+
+	inputMu.Lock()
+	defer inputMu.Unlock()
+
+	if inputhstry == nil {
+		inputhstry = make(InputHistory)
+		_ = store.Load(InputHistoryBucket, &inputhstry)
+	}
+
+	inputhstry.Merge(in.InputHistory)
+	store.Save(InputHistoryBucket, inputhstry)
+}
+
+func (s History) Merge(other History) {
+	for prefix, entries := range other {
+		p, ok := s[prefix]
+		if !ok {
+			p = make(map[string]*HistoryEntry)
+			s[prefix] = p
+		}
+
+		for hash, entry := range entries {
+			if entry == nil {
+				continue
+			}
+
+			h, ok := p[hash]
+			if !ok {
+				h = &HistoryEntry{
+					LastUsed: entry.LastUsed,
+					Used:     entry.Used,
+				}
+
+				h.addUses(entry.Uses...)
+				p[hash] = h
+			} else {
+				h.addUses(entry.Uses...)
+
+				if entry.Used > h.Used {
+					h.Used = entry.Used
+				}
+
+				if entry.LastUsed.After(h.LastUsed) {
+					h.LastUsed = entry.LastUsed
+				}
+			}
+
+			h.DaysSinceUsed = int(time.Since(h.LastUsed).Hours() / 24)
+		}
+	}
+}
+
+// Merge adds the items of other that don't exist yet. The items stay sorted newest first, the ones recorded before
+// their time was tracked come last.
+func (s InputHistory) Merge(other InputHistory) {
+	for module, items := range other {
+		// items are shared with running queries, so the merged ones are a copy
+		merged := slices.Clone(s[module])
+
+		for _, item := range items {
+			exists := slices.ContainsFunc(merged, func(v InputHistoryItem) bool {
+				return v.Term == item.Term && v.Identifier == item.Identifier && v.Time.Equal(item.Time)
+			})
+
+			if !exists {
+				merged = append(merged, item)
+			}
+		}
+
+		slices.SortStableFunc(merged, func(a, b InputHistoryItem) int {
+			return b.Time.Compare(a.Time)
+		})
+
+		s[module] = merged
+	}
+}
@@ -1,10 +1,12 @@
 package history
 
 import (
-	"path/filepath"
+	"encoding/json"
+	"math"
+	"slices"
 	"time"
 
-	"github.com/abenz1267/walker/internal/util"
+	"github.com/abenz1267/walker/internal/store"
 )
 
 type (
@@ -19,10 +21,98 @@ type History HistoryMap
 type HistoryEntry struct {
 	LastUsed      time.Time `json:"last_used,omitempty"`
 	Used          int       `json:"used,omitempty"`
+	Uses          []Use     `json:"uses,omitempty"`
 	DaysSinceUsed int       `json:"-"`
 }
 
-const HistoryName = "history_0.8.14.gob"
+// Use is a single activation of an entry and the context it happened in.
+type Use struct {
+	Time  time.Time `json:"time"`
+	AppId string    `json:"app_id,omitempty"`
+	Cwd   string    `json:"cwd,omitempty"`
+}
+
+// UnmarshalJSON also accepts plain timestamps, as written by older exports.
+func (u *Use) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*u = Use{}
+		return json.Unmarshal(data, &u.Time)
+	}
+
+	type use Use
+
+	return json.Unmarshal(data, (*use)(u))
+}
+
+const (
+	FrecencyCount   = "count"
+	FrecencyBuckets = "buckets"
+	FrecencyDecay   = "decay"
+
+	// maxUses is the amount of timestamps kept per entry.
+	maxUses         = 20
+	defaultHalfLife = 7
+)
+
+var HistoryBucket = store.Bucket{
+	Name:    "history",
+	Version: 3,
+	Legacy:  "history_0.8.14.gob",
+	Migrations: map[int]store.Migration{
+		1: store.Convert(migrateUses),
+		2: store.Convert(migrateContext),
+	},
+}
+
+type historyV2 map[string]map[string]*historyEntryV2
+
+type historyEntryV2 struct {
+	LastUsed time.Time
+	Used     int
+	Uses     []time.Time
+}
+
+// migrateUses fills the timestamps of entries recorded before they were tracked.
+// Only the last usage is known, so it's used for all of them.
+func migrateUses(s historyV2) historyV2 {
+	for _, v := range s {
+		for _, vv := range v {
+			if len(vv.Uses) > 0 {
+				continue
+			}
+
+			for i := 0; i < vv.Used && i < maxUses; i++ {
+				vv.Uses = append(vv.Uses, vv.LastUsed)
+			}
+		}
+	}
+
+	return s
+}
+
+// migrateContext turns the recorded timestamps into uses without context.
+func migrateContext(s historyV2) History {
+	res := History{}
+
+	for prefix, v := range s {
+		res[prefix] = make(map[string]*HistoryEntry)
+
+		for hash, vv := range v {
+			h := &HistoryEntry{
+				LastUsed: vv.LastUsed,
+				Used:     vv.Used,
+			}
+
+			for _, t := range vv.Uses {
+				h.Uses = append(h.Uses, Use{Time: t})
+			}
+
+			res[prefix][hash] = h
+		}
+	}
+
+	return res
+}
 
 func (s *History) Delete(hash string) {
 	for _, v := range *s {
@@ -33,42 +123,42 @@ func (s *History) Delete(hash string) {
 		}
 	}
 
-	util.ToGob(&s, filepath.Join(util.CacheDir(), HistoryName))
+	store.Save(HistoryBucket, s)
 }
 
-func (s History) Save(hash string, prefix string) {
+func (s History) Save(hash string, prefix string, use Use) {
 	p, ok := s[prefix]
 	if !ok {
 		p = make(map[string]*HistoryEntry)
 		s[prefix] = p
 	}
 
+	if use.Time.IsZero() {
+		use.Time = time.Now()
+	}
+
 	h, ok := p[hash]
 	if !ok {
 		h = &HistoryEntry{
-			LastUsed: time.Now(),
+			LastUsed: use.Time,
 			Used:     1,
+			Uses:     []Use{use},
 		}
 	} else {
 		h.Used++
-
-		if h.Used > 10 {
-			h.Used = 10
-		}
-
-		h.LastUsed = time.Now()
+		h.LastUsed = use.Time
+		h.addUses(use)
 	}
 
 	p[hash] = h
 
-	util.ToGob(&s, filepath.Join(util.CacheDir(), HistoryName))
+	store.Save(HistoryBucket, s)
 }
 
 func Get() History {
-	file := filepath.Join(util.CacheDir(), HistoryName)
-
 	history := History{}
-	_ = util.FromGob(file, &history)
+	_ = store.Load(HistoryBucket, &history)
+	history.Migrate()
 
 	for _, v := range history {
 		for _, vv := range v {
@@ -79,3 +169,113 @@ func Get() History {
 
 	return history
 }
+
+// Frecency scores the entry by how often and how recently it was used.
+// "count" only considers the amount of uses and the days since the last one.
+// "buckets" weighs every recorded use by its age, "decay" lets every use lose half its weight per halfLife days.
+func (h *HistoryEntry) Frecency(model string, halfLife float64) float64 {
+	now := time.Now()
+
+	switch model {
+	case FrecencyCount:
+		used := min(h.Used, 10)
+
+		if used == 0 {
+			return 0
+		}
+
+		return float64((10 - h.DaysSinceUsed) * used)
+	case FrecencyBuckets:
+		score := 0.0
+
+		for _, v := range h.Uses {
+			days := now.Sub(v.Time).Hours() / 24
+
+			switch {
+			case days < 4:
+				score += 10
+			case days < 14:
+				score += 7
+			case days < 31:
+				score += 5
+			case days < 90:
+				score += 3
+			default:
+				score += 1
+			}
+		}
+
+		return score
+	default:
+		if halfLife <= 0 {
+			halfLife = defaultHalfLife
+		}
+
+		score := 0.0
+
+		for _, v := range h.Uses {
+			score += 10 * decay(now.Sub(v.Time), halfLife)
+		}
+
+		return score
+	}
+}
+
+// Context scores how well the uses of the entry match the given context.
+// Uses within an hour of the time of day, on the same weekday, with the same focused app or in the same directory count, older uses count less.
+func (h *HistoryEntry) Context(c Use, halfLife float64) float64 {
+	if halfLife <= 0 {
+		halfLife = defaultHalfLife
+	}
+
+	score := 0.0
+
+	for _, v := range h.Uses {
+		points := 0.0
+
+		diff := v.Time.Hour() - c.Time.Hour()
+		if diff < 0 {
+			diff = -diff
+		}
+
+		if diff <= 1 || diff == 23 {
+			points += 1
+		}
+
+		if v.Time.Weekday() == c.Time.Weekday() {
+			points += 1
+		}
+
+		if c.AppId != "" && v.AppId == c.AppId {
+			points += 2
+		}
+
+		if c.Cwd != "" && v.Cwd == c.Cwd {
+			points += 1
+		}
+
+		score += points * decay(c.Time.Sub(v.Time), halfLife)
+	}
+
+	return score
+}
+
+func decay(age time.Duration, halfLife float64) float64 {
+	return math.Pow(0.5, age.Hours()/24/halfLife)
+}
+
+func (h *HistoryEntry) addUses(uses ...Use) {
+	h.Uses = append(h.Uses, uses...)
+
+	slices.SortFunc(h.Uses, func(a, b Use) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	h.Uses = slices.CompactFunc(h.Uses, func(a, b Use) bool {
+		return a.Time.Equal(b.Time) && a.AppId == b.AppId && a.Cwd == b.Cwd
+	})
+
+	if len(h.Uses) > maxUses {
+		h.Uses = slices.Clone(h.Uses[len(h.Uses)-maxUses:])
+	}
+}
@@ -1,21 +1,36 @@
 package history
 
 import (
-	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/abenz1267/walker/internal/util"
+	"github.com/abenz1267/walker/internal/store"
 )
 
 type InputHistoryItem struct {
-	Term       string
-	Identifier string
+	Term       string    `json:"term"`
+	Identifier string    `json:"identifier"`
+	Time       time.Time `json:"time,omitempty"`
 }
 
 type InputHistory map[string][]InputHistoryItem
 
-var inputhstry InputHistory
+var InputHistoryBucket = store.Bucket{
+	Name:    "inputhistory",
+	Version: 1,
+	Legacy:  "inputhistory_0.7.6.gob",
+}
+
+var (
+	inputhstry InputHistory
+	inputMu    sync.Mutex
+)
 
 func SaveInputHistory(module string, input string, identifier string) {
+	inputMu.Lock()
+	defer inputMu.Unlock()
+
 	if inputhstry == nil {
 		inputhstry = make(InputHistory)
 	}
@@ -27,25 +42,70 @@ func SaveInputHistory(module string, input string, identifier string) {
 	n := InputHistoryItem{
 		Term:       input,
 		Identifier: identifier,
+		Time:       time.Now(),
 	}
 
 	inputhstry[module] = append([]InputHistoryItem{n}, inputhstry[module]...)
 
-	util.ToGob(&inputhstry, filepath.Join(util.CacheDir(), "inputhistory_0.7.6.gob"))
+	store.Save(InputHistoryBucket, inputhstry)
 }
 
 func GetInputHistory(module string) []InputHistoryItem {
+	inputMu.Lock()
+	defer inputMu.Unlock()
+
 	if inputhstry != nil {
 		return inputhstry[module]
 	}
 
-	file := filepath.Join(util.CacheDir(), "inputhistory_0.7.6.gob")
-
 	if inputhstry == nil {
 		inputhstry = make(InputHistory)
 	}
 
-	_ = util.FromGob(file, &inputhstry)
+	_ = store.Load(InputHistoryBucket, &inputhstry)
 
 	return inputhstry[module]
 }
+
+// Learned scores the identifiers chosen for the given query. Choices made for exactly this query count fully,
+// choices made for longer queries starting with it count half. Older choices count less, choices recorded before
+// their time was tracked count as if they were halfLife days old.
+func Learned(items []InputHistoryItem, text string, halfLife float64) map[string]float64 {
+	text = strings.ToLower(strings.TrimSpace(text))
+
+	if text == "" || len(items) == 0 {
+		return nil
+	}
+
+	if halfLife <= 0 {
+		halfLife = defaultHalfLife
+	}
+
+	now := time.Now()
+	res := make(map[string]float64)
+
+	for _, v := range items {
+		term := strings.ToLower(strings.TrimSpace(v.Term))
+
+		weight := 0.0
+
+		switch {
+		case term == text:
+			weight = 1
+		case strings.HasPrefix(term, text):
+			weight = 0.5
+		default:
+			continue
+		}
+
+		age := time.Duration(halfLife * 24 * float64(time.Hour))
+
+		if !v.Time.IsZero() {
+			age = now.Sub(v.Time)
+		}
+
+		res[v.Identifier] += 10 * weight * decay(age, halfLife)
+	}
+
+	return res
+}
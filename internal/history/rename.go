@@ -0,0 +1,166 @@
+package history
+
+import (
+	"sync"
+
+	"github.com/abenz1267/walker/internal/store"
+)
+
+// RenamesBucket keeps the renames that weren't migrated yet, f.e. because walker didn't exit cleanly.
+var RenamesBucket = store.Bucket{
+	Name:    "renames",
+	Version: 1,
+}
+
+var (
+	// renames maps legacy identifiers to ids until they are migrated, seen holds the ids handed to Rename since.
+	renames   map[string]string
+	seen      = make(map[string]struct{})
+	dirty     bool
+	renamesMu sync.Mutex
+)
+
+// loadRenames reads the persisted renames once, renamesMu must be held.
+func loadRenames() {
+	if renames != nil {
+		return
+	}
+
+	renames = make(map[string]string)
+	_ = store.Load(RenamesBucket, &renames)
+}
+
+// Rename registers that the entry recorded under its legacy identifier is now identified by id, f.e. because its
+// module started to supply a stable id. legacy is only called the first time an id is seen. The renames are kept in
+// memory until SaveRenames, the records are moved by Migrate.
+func Rename(id string, legacy func() string) {
+	renamesMu.Lock()
+	defer renamesMu.Unlock()
+
+	if _, ok := seen[id]; ok {
+		return
+	}
+
+	seen[id] = struct{}{}
+
+	l := legacy()
+	if l == id {
+		return
+	}
+
+	loadRenames()
+
+	if renames[l] != id {
+		renames[l] = id
+		dirty = true
+	}
+}
+
+// SaveRenames persists the renames registered since the last call.
+func SaveRenames() {
+	renamesMu.Lock()
+	defer renamesMu.Unlock()
+
+	if !dirty {
+		return
+	}
+
+	dirty = false
+	store.Save(RenamesBucket, renames)
+}
+
+// Migrate moves the records of renamed entries in the history and the input history to their new identifier, merging
+// them with records made since. The renames are dropped afterwards. It reports whether the history changed, it's
+// saved in that case.
+func (s History) Migrate() bool {
+	renamesMu.Lock()
+	defer renamesMu.Unlock()
+
+	loadRenames()
+
+	if len(renames) == 0 {
+		return false
+	}
+
+	changed := false
+
+	for _, v := range s {
+		for legacy, old := range v {
+			id, ok := renames[legacy]
+			if !ok {
+				continue
+			}
+
+			delete(v, legacy)
+			changed = true
+
+			h, ok := v[id]
+			if !ok {
+				v[id] = old
+				continue
+			}
+
+			h.Used += old.Used
+			h.addUses(old.Uses...)
+
+			if old.LastUsed.After(h.LastUsed) {
+				h.LastUsed = old.LastUsed
+				h.DaysSinceUsed = old.DaysSinceUsed
+			}
+		}
+	}
+
+	if changed {
+		store.Save(HistoryBucket, s)
+	}
+
+	migrateInput(renames)
+
+	// the next session registers the ids it sees again, so neither grows beyond the entries of a session
+	clear(renames)
+	clear(seen)
+	dirty = false
+	store.Delete(RenamesBucket)
+
+	return changed
+}
+
+// migrateInput moves the input history items of renamed entries to their new identifier.
+func migrateInput(renames map[string]string) {
+	inputMu.Lock()
+	defer inputMu.Unlock()
+
+	if inputhstry == nil {
+		inputhstry = make(InputHistory)
+		_ = store.Load(InputHistoryBucket, &inputhstry)
+	}
+
+	changed := false
+
+	for module, items := range inputhstry {
+		var renamed []InputHistoryItem
+
+		for k, v := range items {
+			id, ok := renames[v.Identifier]
+			if !ok {
+				continue
+			}
+
+			// items are shared with running queries, so they are copied instead of changed in place
+			if renamed == nil {
+				renamed = append([]InputHistoryItem{}, items...)
+			}
+
+			renamed[k].Identifier = id
+		}
+
+		if renamed != nil {
+			inputhstry[module] = renamed
+			changed = true
+		}
+	}
+
+	if changed {
+		store.Save(InputHistoryBucket, inputhstry)
+	}
+}
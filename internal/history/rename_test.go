@@ -0,0 +1,99 @@
+package history
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/abenz1267/walker/internal/store"
+)
+
+func TestRenameMovesHistory(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	used := time.Now().Add(-48 * time.Hour)
+
+	legacy := History{
+		"fi": {
+			"legacy": {LastUsed: used, Used: 3, Uses: []Use{{Time: used}, {Time: used}, {Time: used}}},
+		},
+	}
+
+	store.Save(HistoryBucket, legacy)
+
+	want := legacy["fi"]["legacy"].Frecency(FrecencyDecay, 0)
+
+	calls := 0
+
+	legacyID := func() string {
+		calls++
+		return "legacy"
+	}
+
+	Rename("id", legacyID)
+	Rename("id", legacyID)
+
+	if calls != 1 {
+		t.Errorf("legacy identifier computed %d times", calls)
+	}
+
+	SaveRenames()
+
+	// simulates a restart, the renames have to be read from the store
+	renames = nil
+
+	hstry := Get()
+
+	if _, ok := hstry["fi"]["legacy"]; ok {
+		t.Fatal("legacy record wasn't moved")
+	}
+
+	h, ok := hstry["fi"]["id"]
+	if !ok {
+		t.Fatal("record missing under the new identifier")
+	}
+
+	if h.Used != 3 || !h.LastUsed.Equal(used) || h.DaysSinceUsed != 2 {
+		t.Errorf("got used %d, last used %v, days %d", h.Used, h.LastUsed, h.DaysSinceUsed)
+	}
+
+	if got := h.Frecency(FrecencyDecay, 0); math.Abs(got-want) > 0.01 {
+		t.Errorf("got frecency %f, want %f", got, want)
+	}
+
+	saved := History{}
+
+	if !store.Load(HistoryBucket, &saved) {
+		t.Fatal("history wasn't saved")
+	}
+
+	if _, ok := saved["fi"]["legacy"]; ok || saved["fi"]["id"] == nil {
+		t.Error("migrated history wasn't saved")
+	}
+
+	if len(renames) != 0 || store.Load(RenamesBucket, &map[string]string{}) {
+		t.Error("renames weren't dropped after the migration")
+	}
+}
+
+func TestRenameMovesInputHistory(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	inputhstry = nil
+	renames = nil
+	clear(seen)
+
+	store.Save(InputHistoryBucket, InputHistory{
+		"applications": {{Term: "fi", Identifier: "legacy"}},
+	})
+
+	Rename("id", func() string { return "legacy" })
+
+	History{}.Migrate()
+
+	items := GetInputHistory("applications")
+
+	if len(items) != 1 || items[0].Identifier != "id" {
+		t.Errorf("got %+v", items)
+	}
+}
@@ -2,6 +2,7 @@ package modules
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -9,11 +10,11 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 	"syscall"
 
 	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/store"
 	"github.com/abenz1267/walker/internal/util"
 	"github.com/diamondburned/gotk4/pkg/core/gioutil"
 	"github.com/diamondburned/gotk4/pkg/glib/v2"
@@ -26,9 +27,14 @@ const (
 	ANTHROPIC_API_URL        = "https://api.anthropic.com/v1/messages"
 	ANTHROPIC_AUTH_HEADER    = "x-api-key"
 	ANTHROPIC_API_KEY        = "ANTHROPIC_API_KEY"
-	aiHistoryFile            = "ai_history_0.9.6.gob"
 )
 
+var aiHistoryBucket = store.Bucket{
+	Name:    "ai_history",
+	Version: 1,
+	Legacy:  "ai_history_0.9.6.gob",
+}
+
 type AI struct {
 	config          config.AI
 	entries         []util.Entry
@@ -56,7 +62,7 @@ func (ai *AI) Cleanup() {
 	})
 }
 
-func (ai *AI) Entries(term string) []util.Entry {
+func (ai *AI) Entries(ctx context.Context, term string) []util.Entry {
 	return ai.entries
 }
 
@@ -71,11 +77,9 @@ func (ai *AI) Setup() bool {
 	ai.config = config.Cfg.Builtins.AI
 	ai.terminal = config.Cfg.Terminal
 
-	file := filepath.Join(util.CacheDir(), aiHistoryFile)
-
 	ai.history = make(map[string][]AnthropicMessage)
 
-	util.FromGob(file, &ai.history)
+	store.Load(aiHistoryBucket, &ai.history)
 
 	return true
 }
@@ -216,7 +220,7 @@ func (ai *AI) anthropic(query string) {
 
 	ai.history[ai.currentPrompt.Prompt] = messages
 
-	util.ToGob(&ai.history, filepath.Join(util.CacheDir(), aiHistoryFile))
+	store.Save(aiHistoryBucket, ai.history)
 
 	ai.items.Splice(0, int(ai.items.NItems()), messages...)
 
@@ -2,6 +2,7 @@ package modules
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io/fs"
 	"log"
@@ -15,6 +16,7 @@ import (
 	"github.com/abenz1267/walker/internal/config"
 	"github.com/abenz1267/walker/internal/history"
 	"github.com/abenz1267/walker/internal/modules/windows/wlr"
+	"github.com/abenz1267/walker/internal/store"
 	"github.com/abenz1267/walker/internal/util"
 	"github.com/adrg/xdg"
 	"github.com/djherbis/times"
@@ -23,6 +25,14 @@ import (
 
 const ApplicationsName = "applications"
 
+// ApplicationsBucket caches the parsed desktop files. Caches from before version 2 lack the desktop file ids and are rebuilt.
+var ApplicationsBucket = store.Bucket{
+	Name:        ApplicationsName,
+	Version:     2,
+	Legacy:      "applications.gob",
+	Discardable: true,
+}
+
 type Applications struct {
 	config      config.Applications
 	mu          sync.Mutex
@@ -173,7 +183,7 @@ func (a *Applications) Refresh() {
 	}
 }
 
-func (a *Applications) Entries(term string) []util.Entry {
+func (a *Applications) Entries(ctx context.Context, term string) []util.Entry {
 	if a.config.Actions.HideWithoutQuery && term == "" {
 		entries := []util.Entry{}
 		added := make(map[string]struct{})
@@ -239,7 +249,7 @@ func (a *Applications) parse() []util.Entry {
 	keywordsSingle := fmt.Sprintf("Keywords[%s]=", langSingle)
 
 	if a.config.Cache {
-		ok := util.FromGob(filepath.Join(util.CacheDir(), fmt.Sprintf("%s.gob", ApplicationsName)), &entries)
+		ok := store.Load(ApplicationsBucket, &entries)
 		if ok {
 			return entries
 		}
@@ -288,6 +298,7 @@ func (a *Applications) parse() []util.Entry {
 
 				app := Application{
 					Generic: util.Entry{
+						ID:               desktopID(d, path),
 						Class:            ApplicationsName,
 						History:          a.config.History,
 						Matching:         matching,
@@ -323,7 +334,11 @@ func (a *Applications) parse() []util.Entry {
 							skip = true
 						}
 
-						app.Actions = append(app.Actions, util.Entry{})
+						action := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(line), "[Desktop Action "), "]")
+
+						app.Actions = append(app.Actions, util.Entry{
+							ID: fmt.Sprintf("%s:%s", app.Generic.ID, action),
+						})
 
 						isAction = true
 					}
@@ -559,12 +574,23 @@ func (a *Applications) parse() []util.Entry {
 	}
 
 	if a.config.Cache {
-		util.ToGob(&entries, filepath.Join(util.CacheDir(), fmt.Sprintf("%s.gob", ApplicationsName)))
+		store.Save(ApplicationsBucket, entries)
 	}
 
 	return entries
 }
 
+// desktopID returns the desktop file id as defined by the desktop entry spec: the path relative to the applications
+// directory with slashes replaced by dashes.
+func desktopID(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return filepath.Base(path)
+	}
+
+	return strings.ReplaceAll(rel, string(filepath.Separator), "-")
+}
+
 func transformWineExec(in string) string {
 	splits := strings.Split(in, "wine ")
 	prefix := splits[0]
@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -17,7 +18,7 @@ type Bookmarks struct {
 func (bookmarks *Bookmarks) Cleanup() {
 }
 
-func (bookmarks *Bookmarks) Entries(term string) []util.Entry {
+func (bookmarks *Bookmarks) Entries(ctx context.Context, term string) []util.Entry {
 	hasPrefix := false
 
 	for _, v := range bookmarks.prefixes {
@@ -62,11 +63,13 @@ func (bookmarks *Bookmarks) SetupData() {
 
 	for _, v := range config.Cfg.Builtins.Bookmarks.Entries {
 		bookmarks.entries = append(bookmarks.entries, util.Entry{
+			ID:               v.Url,
 			Label:            v.Label,
 			Sub:              v.Url,
 			Categories:       v.Keywords,
 			Icon:             config.Cfg.Builtins.Bookmarks.GeneralModule.Icon,
 			Exec:             fmt.Sprintf("xdg-open '%s'", v.Url),
+			Actions:          []util.Action{{Name: "copy_url", Label: "Copy URL", Type: util.ActionCopy, Value: v.Url}},
 			Matching:         util.Fuzzy,
 			RecalculateScore: true,
 		})
@@ -79,11 +82,13 @@ func (bookmarks *Bookmarks) SetupData() {
 
 		for _, entry := range v.Entries {
 			bookmarks.entries = append(bookmarks.entries, util.Entry{
+				ID:               entry.Url,
 				Label:            entry.Label,
 				Sub:              fmt.Sprintf("%s: %s", v.Label, entry.Url),
 				Categories:       entry.Keywords,
 				Icon:             config.Cfg.Builtins.Bookmarks.GeneralModule.Icon,
 				Exec:             fmt.Sprintf("xdg-open '%s'", entry.Url),
+				Actions:          []util.Action{{Name: "copy_url", Label: "Copy URL", Type: util.ActionCopy, Value: entry.Url}},
 				Matching:         util.Fuzzy,
 				RecalculateScore: true,
 				Prefix:           v.Prefix,
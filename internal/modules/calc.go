@@ -1,7 +1,8 @@
 package modules
 
 import (
-	"log"
+	"context"
+	"errors"
 	"os/exec"
 	"strings"
 	"unicode"
@@ -19,13 +20,10 @@ func (c *Calc) General() *config.GeneralModule {
 	return &c.config.GeneralModule
 }
 
-func (c Calc) Cleanup() {}
-
-func (c *Calc) Setup() bool {
+func (c *Calc) Setup() error {
 	pth, _ := exec.LookPath("qalc")
 	if pth == "" {
-		log.Println("Calc disabled: currently 'qalc' only.")
-		return false
+		return errors.New("calc disabled: only qalc is supported")
 	}
 
 	pthClip, _ := exec.LookPath("wl-copy")
@@ -39,12 +37,10 @@ func (c *Calc) Setup() bool {
 	cmd := exec.Command("qalc", "-e", "1+1")
 	cmd.Start()
 
-	return true
+	return nil
 }
 
-func (c *Calc) SetupData() {}
-
-func (c Calc) Entries(term string) []util.Entry {
+func (c *Calc) Query(ctx context.Context, term string) ([]util.Entry, error) {
 	if c.config.RequireNumber {
 		hasNumber := false
 
@@ -55,22 +51,22 @@ func (c Calc) Entries(term string) []util.Entry {
 		}
 
 		if !hasNumber {
-			return []util.Entry{}
+			return []util.Entry{}, nil
 		}
 	}
 
 	entries := []util.Entry{}
 
-	cmd := exec.Command("qalc", "-t", term)
+	cmd := commandContext(ctx, "qalc", "-t", term)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		return entries
+		return entries, errors.Join(errors.New("qalc failed"), err)
 	}
 
 	txt := string(out)
 
 	if txt == "" {
-		return entries
+		return entries, nil
 	}
 
 	res := util.Entry{
@@ -89,7 +85,5 @@ func (c Calc) Entries(term string) []util.Entry {
 
 	entries = append(entries, res)
 
-	return entries
+	return entries, nil
 }
-
-func (c *Calc) Refresh() {}
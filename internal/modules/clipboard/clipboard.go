@@ -1,6 +1,7 @@
 package clipboard
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/store"
 	"github.com/abenz1267/walker/internal/util"
 )
 
@@ -22,11 +24,16 @@ const ClipboardName = "clipboard"
 
 var ClipboardSocketAddrUpdate = filepath.Join(util.TmpDir(), "walker-clipboard-update.sock")
 
+var ClipboardBucket = store.Bucket{
+	Name:    ClipboardName,
+	Version: 1,
+	Legacy:  "clipboard.gob",
+}
+
 type Clipboard struct {
 	general         config.GeneralModule
 	items           []ClipboardItem
 	entries         []util.Entry
-	file            string
 	imgTypes        map[string]string
 	max             int
 	exec            string
@@ -51,7 +58,7 @@ func (c *Clipboard) Refresh() {
 
 func (c Clipboard) Cleanup() {}
 
-func (c Clipboard) Entries(term string) []util.Entry {
+func (c Clipboard) Entries(ctx context.Context, term string) []util.Entry {
 	return c.entries
 }
 
@@ -64,7 +71,6 @@ func (c *Clipboard) Setup() bool {
 
 	c.general = config.Cfg.Builtins.Clipboard.GeneralModule
 
-	c.file = filepath.Join(util.CacheDir(), "clipboard.gob")
 	c.max = config.Cfg.Builtins.Clipboard.MaxEntries
 	c.exec = config.Cfg.Builtins.Clipboard.Exec
 	c.avoidLineBreaks = config.Cfg.Builtins.Clipboard.AvoidLineBreaks
@@ -79,11 +85,11 @@ func (c *Clipboard) Setup() bool {
 
 func (c *Clipboard) SetupData() {
 	current := []ClipboardItem{}
-	_ = util.FromGob(c.file, &current)
+	_ = store.Load(ClipboardBucket, &current)
 
 	go c.watch()
 
-	c.items = clean(current, c.file)
+	c.items = clean(current)
 
 	for _, v := range c.items {
 		c.entries = append(c.entries, itemToEntry(v, c.exec, c.avoidLineBreaks))
@@ -93,7 +99,7 @@ func (c *Clipboard) SetupData() {
 	c.general.HasInitialSetup = true
 }
 
-func clean(entries []ClipboardItem, file string) []ClipboardItem {
+func clean(entries []ClipboardItem) []ClipboardItem {
 	cleaned := []ClipboardItem{}
 
 	for _, v := range entries {
@@ -107,7 +113,7 @@ func clean(entries []ClipboardItem, file string) []ClipboardItem {
 		}
 	}
 
-	util.ToGob(&cleaned, file)
+	store.Save(ClipboardBucket, cleaned)
 
 	return cleaned
 }
@@ -251,7 +257,7 @@ func (c *Clipboard) watch() {
 			c.entries = slices.Clone(c.entries[:c.max])
 		}
 
-		util.ToGob(&c.items, c.file)
+		store.Save(ClipboardBucket, c.items)
 	}
 }
 
@@ -321,5 +327,5 @@ func (c *Clipboard) Delete(entry util.Entry) {
 		c.entries = append(c.entries, itemToEntry(v, c.exec, c.avoidLineBreaks))
 	}
 
-	util.ToGob(&c.items, c.file)
+	store.Save(ClipboardBucket, c.items)
 }
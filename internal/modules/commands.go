@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"context"
 	"github.com/abenz1267/walker/internal/config"
 	"github.com/abenz1267/walker/internal/util"
 )
@@ -16,7 +17,7 @@ func (c *Commands) General() *config.GeneralModule {
 
 func (c Commands) Cleanup() {}
 
-func (c Commands) Entries(term string) []util.Entry {
+func (c Commands) Entries(ctx context.Context, term string) []util.Entry {
 	return c.entries
 }
 
@@ -51,6 +52,10 @@ func (c *Commands) SetupData() {
 			label: "Adjust Theme",
 			exec:  "adjusttheme",
 		},
+		{
+			label: "Reload Plugins",
+			exec:  "reloadplugins",
+		},
 	}
 
 	for _, v := range entries {
@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"context"
 	"github.com/abenz1267/walker/internal/config"
 	"github.com/abenz1267/walker/internal/util"
 )
@@ -16,7 +17,7 @@ func (c *CustomCommands) General() *config.GeneralModule {
 
 func (c CustomCommands) Cleanup() {}
 
-func (c CustomCommands) Entries(term string) (_ []util.Entry) {
+func (c CustomCommands) Entries(ctx context.Context, term string) (_ []util.Entry) {
 	return c.entries
 }
 
@@ -2,114 +2,258 @@ package modules
 
 import (
 	"bufio"
-	"fmt"
+	"context"
+	"encoding/json"
 	"log"
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/abenz1267/walker/internal/config"
 	"github.com/abenz1267/walker/internal/util"
 )
 
-var (
-	DmenuSocketAddrGet   = filepath.Join(util.TmpDir(), "walker-dmenu.sock")
-	DmenuSocketAddrReply = filepath.Join(util.TmpDir(), "walker-dmenu-reply.sock")
+var DmenuSocketAddrGet = filepath.Join(util.TmpDir(), "walker-dmenu.sock")
+
+const (
+	// DmenuCancelled is the exit code when the window is closed without a selection.
+	DmenuCancelled = 2
+
+	// DmenuCustom is the exit code of the first custom keybind, the following ones count up, like in rofi.
+	DmenuCustom = 10
 )
 
+// dmenuBatchDelay collects lines arriving in quick succession, so the list isn't updated for every single one.
+const dmenuBatchDelay = 50 * time.Millisecond
+
 type Dmenu struct {
 	Config             config.Dmenu
-	Content            []string
 	initialSeparator   string
 	initialLabelColumn int
+	initialJsonLines   bool
+	initialFormat      string
 	IsService          bool
+
+	// sessions are the clients waiting for a reply, active is the one that is shown
+	mu       sync.Mutex
+	sessions map[string]*dmenuSession
+	active   *dmenuSession
+
+	// conn is the client's connection to the service
+	conn *net.UnixConn
 }
 
 func (d *Dmenu) General() *config.GeneralModule {
 	return &d.Config.GeneralModule
 }
 
-func (d Dmenu) Entries(term string) []util.Entry {
-	entries := []util.Entry{}
+// DmenuLine is a line of input with JsonLines set.
+type DmenuLine struct {
+	Label         string `json:"label"`
+	Sub           string `json:"sub"`
+	Icon          string `json:"icon"`
+	Searchable    string `json:"searchable"`
+	Value         string `json:"value"`
+	Nonselectable bool   `json:"nonselectable"`
+	Urgent        bool   `json:"urgent"`
+	Active        bool   `json:"active"`
+}
 
-	for _, v := range d.Content {
-		label := v
+func (d *Dmenu) Entries(ctx context.Context, term string) []util.Entry {
+	lines, _, _ := d.current().lines(0)
 
-		if d.Config.LabelColumn > 0 {
-			split := strings.Split(v, d.Config.Separator)
+	return d.entries(lines, 0)
+}
 
-			if len(split) >= d.Config.LabelColumn {
-				label = split[d.Config.LabelColumn-1]
-			}
+// StreamEntries pushes the lines received so far and keeps pushing new ones until the input is done.
+func (d *Dmenu) StreamEntries(ctx context.Context, term string, push func([]util.Entry)) {
+	s := d.current()
+
+	n := 0
+
+	for {
+		lines, done, changed := s.lines(n)
+
+		if len(lines) > 0 {
+			push(d.entries(lines, n))
+		}
+
+		n += len(lines)
+
+		if done {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return
 		}
 
-		entries = append(entries, util.Entry{
-			Label: label,
-			Sub:   "Dmenu",
-			Exec:  v,
-		})
+		select {
+		case <-time.After(dmenuBatchDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// entries turns lines into entries, offset is the index of the first line in the input.
+func (d *Dmenu) entries(lines []string, offset int) []util.Entry {
+	entries := []util.Entry{}
+
+	for k, v := range lines {
+		entry, ok := d.entry(v)
+		if !ok {
+			continue
+		}
+
+		entry.Index = offset + k
+
+		entries = append(entries, entry)
 	}
 
 	return entries
 }
 
-func (Dmenu) Reply(res string) {
-	if !util.FileExists(DmenuSocketAddrReply) {
-		return
+// entry turns a line of input into an entry. Lines are either json or plain text, optionally with rofi's row options.
+func (d *Dmenu) entry(v string) (util.Entry, bool) {
+	if d.Config.JsonLines {
+		return d.jsonEntry(v)
 	}
 
-	conn, err := net.Dial("unix", DmenuSocketAddrReply)
-	if err != nil {
-		log.Println(err)
-		return
+	if strings.Contains(v, "\x00") {
+		return rofiEntry(v), true
 	}
-	defer conn.Close()
 
-	_, err = conn.Write([]byte(res))
+	label := v
+
+	if d.Config.LabelColumn > 0 {
+		split := strings.Split(v, d.Config.Separator)
+
+		if len(split) >= d.Config.LabelColumn {
+			label = split[d.Config.LabelColumn-1]
+		}
+	}
+
+	return util.Entry{
+		Label: label,
+		Sub:   "Dmenu",
+		Exec:  v,
+	}, true
+}
+
+func (d *Dmenu) jsonEntry(v string) (util.Entry, bool) {
+	if strings.TrimSpace(v) == "" {
+		return util.Entry{}, false
+	}
+
+	line := DmenuLine{}
+
+	err := json.Unmarshal([]byte(v), &line)
 	if err != nil {
 		log.Println(err)
+		return util.Entry{}, false
 	}
+
+	return line.entry(), line.Label != ""
 }
 
-func (d Dmenu) ListenForReply() bool {
-	os.Remove(DmenuSocketAddrReply)
+func (l DmenuLine) entry() util.Entry {
+	entry := util.Entry{
+		Label:         l.Label,
+		Sub:           l.Sub,
+		Icon:          l.Icon,
+		Searchable:    l.Searchable,
+		Exec:          l.Value,
+		Nonselectable: l.Nonselectable,
+	}
 
-	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: DmenuSocketAddrReply})
-	if err != nil {
-		panic(err)
+	if entry.Sub == "" {
+		entry.Sub = "Dmenu"
 	}
-	defer l.Close()
 
-	for {
-		conn, err := l.AcceptUnix()
-		if err != nil {
-			log.Panic(err)
-		}
+	if entry.Exec == "" {
+		entry.Exec = l.Label
+	}
 
-		b := make([]byte, 1_048_576)
-		i, err := conn.Read(b)
-		if err != nil {
-			if err.Error() == "EOF" {
-				break
-			} else {
-				log.Panic(err)
-			}
-			continue
-		}
+	switch {
+	case l.Urgent:
+		entry.Class = "urgent"
+	case l.Active:
+		entry.Class = "active"
+	}
 
-		res := string(b[:i])
+	return entry
+}
 
-		if res != "CNCLD" {
-			fmt.Println(res)
-		} else {
-			return true
-		}
+// rofiEntry parses a line with rofi's row options, f.e. "Firefox\0icon\x1ffirefox". "display" replaces the label, "info"
+// is returned instead of the text, "sub" is specific to walker.
+func rofiEntry(v string) util.Entry {
+	text, opts := rofiOptions(v)
+
+	line := DmenuLine{
+		Label:         text,
+		Sub:           opts["sub"],
+		Icon:          opts["icon"],
+		Searchable:    opts["meta"],
+		Value:         opts["info"],
+		Nonselectable: opts["nonselectable"] == "true",
+		Urgent:        opts["urgent"] == "true",
+		Active:        opts["active"] == "true",
+	}
+
+	if line.Value == "" {
+		line.Value = text
+	}
+
+	if display, ok := opts["display"]; ok {
+		line.Label = display
+	}
+
+	return line.entry()
+}
+
+// Output formats the selection according to the format option, like rofi's "-format": "s" is the selection, "i" its
+// index, "d" the index starting at 1, "f" the query, "q" and "F" are the quoted selection and query. Digits are
+// replaced with that column of the selection. The index is -1 if nothing was selected.
+func (d *Dmenu) Output(selection string, index int, query string) string {
+	if d.Config.Format == "" {
+		return selection
+	}
 
-		break
+	var b strings.Builder
+
+	for _, c := range d.Config.Format {
+		switch {
+		case c == 's':
+			b.WriteString(selection)
+		case c == 'i':
+			b.WriteString(strconv.Itoa(index))
+		case c == 'd':
+			b.WriteString(strconv.Itoa(index + 1))
+		case c == 'f':
+			b.WriteString(query)
+		case c == 'q':
+			b.WriteString(shellQuote(selection))
+		case c == 'F':
+			b.WriteString(shellQuote(query))
+		case c >= '1' && c <= '9':
+			columns := strings.Split(selection, d.Config.Separator)
+
+			if n := int(c - '0'); n <= len(columns) {
+				b.WriteString(columns[n-1])
+			}
+		default:
+			b.WriteRune(c)
+		}
 	}
 
-	return false
+	return b.String()
 }
 
 func (d *Dmenu) Setup() bool {
@@ -119,6 +263,8 @@ func (d *Dmenu) Setup() bool {
 
 	d.initialSeparator = d.Config.Separator
 	d.initialLabelColumn = d.Config.LabelColumn
+	d.initialJsonLines = d.Config.JsonLines
+	d.initialFormat = d.Config.Format
 
 	d.Config.SwitcherOnly = true
 
@@ -128,77 +274,29 @@ func (d *Dmenu) Setup() bool {
 func (d *Dmenu) Cleanup() {
 	d.Config.Separator = d.initialSeparator
 	d.Config.LabelColumn = d.initialLabelColumn
+	d.Config.JsonLines = d.initialJsonLines
+	d.Config.Format = d.initialFormat
 }
 
-func (d *Dmenu) StartListening() {
-	os.Remove(DmenuSocketAddrGet)
-
-	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: DmenuSocketAddrGet})
-	if err != nil {
-		panic(err)
-	}
-	defer l.Close()
-
-	for {
-		conn, err := l.AcceptUnix()
-		if err != nil {
-			log.Panic(err)
-		}
-
-		b := make([]byte, 1_048_576)
-		i, err := conn.Read(b)
-		if err != nil {
-			log.Println(err)
-			continue
-		}
-
-		res := strings.Split(string(b[:i]), "\n")
-
-		d.Content = []string{}
-
-		for _, v := range res {
-			if v != "" {
-				d.Content = append(d.Content, v)
-			}
-		}
-	}
-}
-
-func (d Dmenu) Send() {
-	conn, err := net.Dial("unix", DmenuSocketAddrGet)
-	if err != nil {
-		log.Panic(err)
-	}
-	defer conn.Close()
-
-	scanner := bufio.NewScanner(os.Stdin)
+// SetupData starts receiving input, it's shown while it's still arriving.
+func (d *Dmenu) SetupData() {
+	if !d.Config.HasInitialSetup {
+		if config.Cfg.IsService {
+			d.IsService = true
+			go d.StartListening()
+		} else {
+			s := &dmenuSession{}
 
-	for scanner.Scan() {
-		msg := fmt.Sprintf("%s\n", scanner.Text())
+			d.mu.Lock()
+			d.active = s
+			d.mu.Unlock()
 
-		_, err = conn.Write([]byte(msg))
-		if err != nil {
-			log.Panic(err)
+			go s.read(bufio.NewReader(os.Stdin))
 		}
 	}
-}
-
-func (d *Dmenu) SetupData() {
-	if config.Cfg.IsService {
-		go d.StartListening()
-		d.IsService = true
-	}
 
 	d.Config.IsSetup = true
 	d.Config.HasInitialSetup = true
-
-	if !d.IsService {
-		scanner := bufio.NewScanner(os.Stdin)
-
-		for scanner.Scan() {
-			d.Content = append(d.Content, scanner.Text())
-		}
-	}
 }
 
 func (d *Dmenu) Refresh() {
@@ -0,0 +1,345 @@
+package modules
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// dmenuSession is the input of a single dmenu client. In service mode each client has its own connection, which
+// carries its input and, once something was selected, the reply.
+type dmenuSession struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	content []string
+	done    bool
+	closed  bool
+	changed chan struct{}
+}
+
+// read adds the lines of r until r is done or the session was closed.
+func (s *dmenuSession) read(r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+
+		line = strings.TrimRight(line, "\r\n")
+
+		if line != "" && !s.add(line) {
+			return
+		}
+
+		if err != nil {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			// replying closes the connection, so reading fails
+			if err != io.EOF && !s.closed {
+				log.Println(err)
+			}
+
+			s.done = true
+			s.notify()
+
+			return
+		}
+	}
+}
+
+func (s *dmenuSession) add(line string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.content = append(s.content, line)
+	s.notify()
+
+	return true
+}
+
+// lines returns the lines from index from on, whether the input is done and a channel that's closed on the next change.
+func (s *dmenuSession) lines(from int) ([]string, bool, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from = min(from, len(s.content))
+
+	if s.changed == nil {
+		s.changed = make(chan struct{})
+	}
+
+	return s.content[from:len(s.content):len(s.content)], s.done, s.changed
+}
+
+// notify wakes up the streams waiting for changes, s.mu must be held.
+func (s *dmenuSession) notify() {
+	if s.changed != nil {
+		close(s.changed)
+		s.changed = nil
+	}
+}
+
+// reply sends the exit code and the output to the client and closes the connection. The first line of the reply is
+// the exit code.
+func (s *dmenuSession) reply(code int, res string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.closed = true
+
+	if s.conn == nil {
+		return
+	}
+
+	defer s.conn.Close()
+
+	_, err := fmt.Fprintf(s.conn, "%d\n%s", code, res)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// hungUp reports whether the client closed its side of the connection completely. A client that only finished its
+// input still waits for the reply.
+func (s *dmenuSession) hungUp() bool {
+	s.mu.Lock()
+	conn, ok := s.conn.(*net.UnixConn)
+	s.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return false
+	}
+
+	hup := false
+
+	_ = raw.Control(func(fd uintptr) {
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+
+		n, err := unix.Poll(fds, 0)
+		hup = err == nil && n > 0 && fds[0].Revents&unix.POLLHUP != 0
+	})
+
+	return hup
+}
+
+// session returns the session with the given id, it's created if it doesn't exist yet. The client connects before the
+// window is opened, but the order in which both arrive isn't guaranteed.
+func (d *Dmenu) session(id string) *dmenuSession {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.sessions == nil {
+		d.sessions = make(map[string]*dmenuSession)
+	}
+
+	s, ok := d.sessions[id]
+	if !ok {
+		s = &dmenuSession{}
+		d.sessions[id] = s
+	}
+
+	return s
+}
+
+// current returns the session that is shown.
+func (d *Dmenu) current() *dmenuSession {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.active == nil {
+		return &dmenuSession{done: true}
+	}
+
+	return d.active
+}
+
+// Open shows the session with the given id. A previously shown session that didn't get a reply is cancelled.
+func (d *Dmenu) Open(id string) {
+	d.Cancel()
+
+	s := d.session(id)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.active = s
+}
+
+// Reply sends the output and the exit code to the client of the shown session and ends it.
+func (d *Dmenu) Reply(code int, res string) {
+	d.mu.Lock()
+
+	s := d.active
+	d.active = nil
+
+	for k, v := range d.sessions {
+		if v == s {
+			delete(d.sessions, k)
+		}
+	}
+
+	d.mu.Unlock()
+
+	if s != nil {
+		s.reply(code, res)
+	}
+}
+
+// Cancel ends the shown session without a selection, unless it already got a reply.
+func (d *Dmenu) Cancel() {
+	d.Reply(DmenuCancelled, "")
+}
+
+// HungUp reports whether the client of the session went away, f.e. because it was killed while its session was queued.
+func (d *Dmenu) HungUp(id string) bool {
+	d.mu.Lock()
+	s, ok := d.sessions[id]
+	d.mu.Unlock()
+
+	return ok && s.hungUp()
+}
+
+// Drop forgets the session with the given id without replying, its client is gone.
+func (d *Dmenu) Drop(id string) {
+	d.mu.Lock()
+	s, ok := d.sessions[id]
+	delete(d.sessions, id)
+	d.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+
+	if s.conn != nil {
+		s.conn.Close()
+	}
+}
+
+// StartListening receives the input of dmenu clients, one connection per session. The first line is the id of the
+// session, the following ones are the input. The input ends when the client closes its side of the connection.
+func (d *Dmenu) StartListening() {
+	os.Remove(DmenuSocketAddrGet)
+
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: DmenuSocketAddrGet})
+	if err != nil {
+		panic(err)
+	}
+	defer l.Close()
+
+	for {
+		conn, err := l.AcceptUnix()
+		if err != nil {
+			log.Panic(err)
+		}
+
+		go func() {
+			r := bufio.NewReader(conn)
+
+			id, err := r.ReadString('\n')
+			if err != nil {
+				log.Println(err)
+				conn.Close()
+				return
+			}
+
+			s := d.session(strings.TrimSuffix(id, "\n"))
+
+			s.mu.Lock()
+			s.conn = conn
+			s.mu.Unlock()
+
+			s.read(r)
+		}()
+	}
+}
+
+// Send connects to the service and streams stdin to it in the background. It returns the id of the session, which has
+// to be passed to the service with "--dmenusession".
+func (d *Dmenu) Send() string {
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: DmenuSocketAddrGet})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	d.conn = conn
+
+	// pids can be reused by the time a queued session is shown
+	b := make([]byte, 8)
+
+	_, err = rand.Read(b)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	id := hex.EncodeToString(b)
+
+	_, err = fmt.Fprintf(conn, "%s\n", id)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	go func() {
+		// the service hangs up once the window is closed, the remaining input isn't needed then
+		_, err := io.Copy(conn, os.Stdin)
+		if err != nil && !errors.Is(err, syscall.EPIPE) {
+			log.Println(err)
+		}
+
+		conn.CloseWrite()
+	}()
+
+	return id
+}
+
+// Wait prints the output of the service and returns the exit code.
+func (d *Dmenu) Wait() int {
+	defer d.conn.Close()
+
+	// the reply ends when the service closes the connection
+	b, err := io.ReadAll(d.conn)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	c, res, _ := strings.Cut(string(b), "\n")
+
+	code, err := strconv.Atoi(c)
+	if err != nil {
+		log.Println("dmenu: no reply from the service")
+		return DmenuCancelled
+	}
+
+	if code != DmenuCancelled && res != "" {
+		fmt.Println(res)
+	}
+
+	return code
+}
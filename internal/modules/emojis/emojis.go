@@ -2,6 +2,7 @@ package emojis
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"strings"
 
@@ -28,7 +29,7 @@ func (e *Emojis) General() *config.GeneralModule {
 
 func (e Emojis) Cleanup() {}
 
-func (e Emojis) Entries(term string) []util.Entry {
+func (e Emojis) Entries(ctx context.Context, term string) []util.Entry {
 	return e.entries
 }
 
@@ -3,6 +3,7 @@ package modules
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -35,8 +36,11 @@ func (f *Finder) Refresh() {
 	f.config.IsSetup = false
 }
 
-func (f *Finder) Entries(term string) []util.Entry {
+func (f *Finder) Entries(ctx context.Context, term string) []util.Entry {
 	for !f.hasList {
+		if ctx.Err() != nil {
+			return []util.Entry{}
+		}
 	}
 
 	entries := []util.Entry{}
@@ -60,7 +64,11 @@ func (f *Finder) Entries(term string) []util.Entry {
 		}
 	}
 
-	for _, v := range toCheck {
+	for k, v := range toCheck {
+		if k%1000 == 0 && ctx.Err() != nil {
+			return []util.Entry{}
+		}
+
 		var score float64
 		var pos *[]int
 
@@ -101,6 +109,10 @@ func (f *Finder) Entries(term string) []util.Entry {
 				Categories:       []string{"finder", "fzf"},
 				Class:            "finder",
 				Matching:         util.Fuzzy,
+				Actions: []util.Action{
+					{Name: "reveal", Label: "Open folder", Type: util.ActionReveal, Value: ddd},
+					{Name: "copy_path", Label: "Copy path", Type: util.ActionCopy, Value: ddd},
+				},
 			}
 
 			res := ""
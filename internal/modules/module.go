@@ -0,0 +1,194 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+
+	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/util"
+)
+
+var ErrNotSupported = errors.New("not supported by module")
+
+// Module is the interface for new modules. Unlike Workable it reports errors and everything beyond querying is an
+// optional capability, see below. Adapt turns a Module into a Workable, so both kinds can be used side by side. So far
+// only calc is a Module, the other builtins are still Workable.
+type Module interface {
+	General() *config.GeneralModule
+
+	// Setup reads the config and checks requirements, an error disables the module.
+	Setup() error
+
+	Query(ctx context.Context, term string) ([]util.Entry, error)
+}
+
+// Loader loads the module's data before the first query.
+type Loader interface {
+	Load(ctx context.Context) error
+}
+
+// Refresher updates the module's data when the window is opened again. Without it, the data is loaded again if the
+// module's "refresh" option is set.
+type Refresher interface {
+	Refresh()
+}
+
+// Streamer delivers entries in batches as they arrive, it's used instead of Query.
+type Streamer interface {
+	Stream(ctx context.Context, term string, push func([]util.Entry)) error
+}
+
+// Previewer describes an entry in more detail than its label and sub. No module implements it yet, previews are only
+// served by the "preview" action of the control socket and the window doesn't show them.
+type Previewer interface {
+	Preview(ctx context.Context, entry util.Entry) (string, error)
+}
+
+// Actioner adds actions to the entries of the module.
+type Actioner interface {
+	Actions(entry util.Entry) []util.Action
+}
+
+// MinQuery is implemented by modules that only answer queries of a minimum length, in addition to "min_chars".
+type MinQuery interface {
+	MinQueryLength() int
+}
+
+// Opener is notified when the window is opened, in service mode this happens once per use.
+type Opener interface {
+	Open()
+}
+
+// Closer is notified when the window is closed.
+type Closer interface {
+	Close()
+}
+
+// Reporting is implemented by modules whose queries can fail. The engine uses it instead of Entries to surface errors.
+type Reporting interface {
+	QueryStream(ctx context.Context, term string, push func([]util.Entry)) error
+}
+
+// Adapter runs a Module as Workable.
+type Adapter struct {
+	Module Module
+}
+
+func Adapt(m Module) *Adapter {
+	return &Adapter{Module: m}
+}
+
+func (a *Adapter) General() *config.GeneralModule {
+	return a.Module.General()
+}
+
+func (a *Adapter) Setup() bool {
+	err := a.Module.Setup()
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	return true
+}
+
+func (a *Adapter) SetupData() {
+	if l, ok := a.Module.(Loader); ok {
+		err := l.Load(context.Background())
+		if err != nil {
+			log.Printf("%s: %s\n", a.General().Name, err)
+		}
+	}
+
+	a.General().IsSetup = true
+	a.General().HasInitialSetup = true
+}
+
+func (a *Adapter) Refresh() {
+	if r, ok := a.Module.(Refresher); ok {
+		r.Refresh()
+		return
+	}
+
+	a.General().IsSetup = !a.General().Refresh
+}
+
+func (a *Adapter) Cleanup() {
+	if c, ok := a.Module.(Closer); ok {
+		c.Close()
+	}
+}
+
+func (a *Adapter) Open() {
+	if o, ok := a.Module.(Opener); ok {
+		o.Open()
+	}
+}
+
+// MinQueryLength hands the module's minimum to the engine, which skips shorter queries.
+func (a *Adapter) MinQueryLength() int {
+	if m, ok := a.Module.(MinQuery); ok {
+		return m.MinQueryLength()
+	}
+
+	return 0
+}
+
+func (a *Adapter) Preview(ctx context.Context, entry util.Entry) (string, error) {
+	if p, ok := a.Module.(Previewer); ok {
+		return p.Preview(ctx, entry)
+	}
+
+	return "", ErrNotSupported
+}
+
+func (a *Adapter) Entries(ctx context.Context, term string) []util.Entry {
+	entries := []util.Entry{}
+
+	err := a.QueryStream(ctx, term, func(e []util.Entry) {
+		entries = append(entries, e...)
+	})
+	if err != nil {
+		logUnlessCancelled(ctx, err)
+	}
+
+	return entries
+}
+
+func (a *Adapter) StreamEntries(ctx context.Context, term string, push func([]util.Entry)) {
+	err := a.QueryStream(ctx, term, push)
+	if err != nil {
+		logUnlessCancelled(ctx, err)
+	}
+}
+
+// QueryStream streams or queries the entries, depending on what the module supports, and adds their actions.
+func (a *Adapter) QueryStream(ctx context.Context, term string, push func([]util.Entry)) error {
+	pushWithActions := func(e []util.Entry) {
+		if ac, ok := a.Module.(Actioner); ok {
+			// modules may hand out their cached entries, so those aren't changed
+			e = slices.Clone(e)
+
+			for k := range e {
+				e[k].Actions = append(slices.Clip(e[k].Actions), ac.Actions(e[k])...)
+			}
+		}
+
+		push(e)
+	}
+
+	if s, ok := a.Module.(Streamer); ok {
+		return s.Stream(ctx, term, pushWithActions)
+	}
+
+	entries, err := a.Module.Query(ctx, term)
+	if err != nil {
+		return err
+	}
+
+	pushWithActions(entries)
+
+	return nil
+}
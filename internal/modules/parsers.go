@@ -0,0 +1,272 @@
+package modules
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/abenz1267/walker/internal/util"
+)
+
+const (
+	ParserJSON   = "json"
+	ParserNDJSON = "ndjson"
+	ParserKV     = "kv"
+	ParserCSV    = "csv"
+	ParserTSV    = "tsv"
+	ParserRofi   = "rofi"
+)
+
+// parse turns the output of the plugin into entries according to its parser.
+func (e Plugin) parse(out []byte) []util.Entry {
+	if e.Config.Parser == ParserJSON {
+		return e.parseJson(out)
+	}
+
+	parseLine := e.lineParser()
+	if parseLine == nil {
+		log.Printf("plugin %s: unknown parser '%s'\n", e.Config.Name, e.Config.Parser)
+		return nil
+	}
+
+	var entries []util.Entry
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+
+	for scanner.Scan() {
+		if entry, ok := parseLine(scanner.Text()); ok {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// lineParser returns a parser for a single line of output, or nil if the output can't be parsed line by line.
+// It keeps state between lines, f.e. the header of csv output, so a new one is needed per output.
+func (e Plugin) lineParser() func(line string) (util.Entry, bool) {
+	switch e.Config.Parser {
+	case ParserKV:
+		return e.parseKvLine
+	case ParserNDJSON:
+		return e.parseNdjsonLine
+	case ParserRofi:
+		return parseRofiLine
+	case ParserCSV, ParserTSV:
+		columns := e.Config.Columns
+		header := e.Config.Header
+
+		return func(line string) (util.Entry, bool) {
+			fields, ok := e.splitColumns(line)
+			if !ok {
+				return util.Entry{}, false
+			}
+
+			if header {
+				header = false
+
+				if len(columns) == 0 {
+					columns = fields
+				}
+
+				return util.Entry{}, false
+			}
+
+			return parseColumns(columns, fields)
+		}
+	}
+
+	return nil
+}
+
+func (e Plugin) parseKvLine(line string) (util.Entry, bool) {
+	pairs := strings.Split(line, e.Config.KvSeparator)
+
+	entry := util.Entry{}
+
+	for _, v := range pairs {
+		key, val, ok := strings.Cut(v, "=")
+		if !ok {
+			continue
+		}
+
+		setField(&entry, key, val)
+	}
+
+	return entry, entry.Label != ""
+}
+
+func (e Plugin) parseNdjsonLine(line string) (util.Entry, bool) {
+	line = strings.TrimSpace(line)
+
+	if line == "" {
+		return util.Entry{}, false
+	}
+
+	var entry util.Entry
+
+	err := json.Unmarshal([]byte(line), &entry)
+	if err != nil {
+		log.Printf("plugin %s: %s\n", e.Config.Name, err)
+		return entry, false
+	}
+
+	return entry, entry.Label != ""
+}
+
+// splitColumns splits a line of csv or tsv output. Quoted fields can't span multiple lines.
+func (e Plugin) splitColumns(line string) ([]string, bool) {
+	if strings.TrimSpace(line) == "" {
+		return nil, false
+	}
+
+	r := csv.NewReader(strings.NewReader(line))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	if e.Config.Parser == ParserTSV {
+		r.Comma = '\t'
+	}
+
+	fields, err := r.Read()
+	if err != nil {
+		log.Printf("plugin %s: %s\n", e.Config.Name, err)
+		return nil, false
+	}
+
+	return fields, true
+}
+
+// parseColumns maps the fields of a row to the entry fields named by columns. Empty names skip a column.
+func parseColumns(columns []string, fields []string) (util.Entry, bool) {
+	entry := util.Entry{}
+
+	for k, v := range fields {
+		if k >= len(columns) || columns[k] == "" {
+			continue
+		}
+
+		setField(&entry, columns[k], v)
+	}
+
+	return entry, entry.Label != ""
+}
+
+// parseRofiLine parses a row as printed by rofi scripts: the label, optionally followed by "\0" and options separated
+// by "\x1f", f.e. "Firefox\0icon\x1ffirefox\x1finfo\x1fsome data". Rows starting with "\0" set options of rofi itself
+// and are skipped.
+func parseRofiLine(line string) (util.Entry, bool) {
+	label, opts := rofiOptions(line)
+
+	entry := util.Entry{
+		Label:      label,
+		Icon:       opts["icon"],
+		Searchable: opts["meta"],
+		Value:      opts["info"],
+	}
+
+	return entry, entry.Label != ""
+}
+
+// rofiOptions splits a rofi row into its text and its options.
+func rofiOptions(line string) (string, map[string]string) {
+	text, options, _ := strings.Cut(line, "\x00")
+
+	res := make(map[string]string)
+
+	opts := strings.Split(options, "\x1f")
+
+	for i := 0; i+1 < len(opts); i += 2 {
+		res[opts[i]] = opts[i+1]
+	}
+
+	return text, res
+}
+
+// setField sets the entry field with the given key, as used by the kv parser and the column mapping.
+func setField(entry *util.Entry, key, val string) {
+	switch key {
+	case "id":
+		entry.ID = val
+	case "path":
+		entry.Path = val
+	case "score_final":
+		score, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		entry.ScoreFinal = score
+	case "score_fuzzy":
+		score, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		entry.ScoreFuzzy = score
+	case "recalculate_score":
+		entry.RecalculateScore, _ = strconv.ParseBool(val)
+	case "label":
+		entry.Label = val
+	case "sub":
+		entry.Sub = val
+	case "exec":
+		entry.Exec = val
+	case "image":
+		entry.Image = val
+	case "icon":
+		entry.Icon = val
+	case "exec_alt":
+		entry.ExecAlt = val
+	case "class":
+		entry.Class = val
+	case "initial_class":
+		entry.InitialClass = val
+	case "matching":
+		mt, err := strconv.Atoi(val)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		entry.Matching = util.MatchingType(mt)
+	case "match_fields":
+		entry.MatchFields, _ = strconv.Atoi(val)
+	case "searchable":
+		entry.Searchable = val
+	case "searchable2":
+		entry.Searchable2 = val
+	case "categories":
+		entry.Categories = strings.Split(val, ",")
+	case "terminal":
+		entry.Terminal, _ = strconv.ParseBool(val)
+	case "prefer":
+		entry.Prefer, _ = strconv.ParseBool(val)
+	case "drag_drop":
+		entry.DragDrop, _ = strconv.ParseBool(val)
+	case "drag_drop_data":
+		entry.DragDropData = val
+	case "hide_text":
+		entry.HideText, _ = strconv.ParseBool(val)
+	case "value":
+		entry.Value = val
+	}
+}
+
+func (e Plugin) parseJson(out []byte) []util.Entry {
+	var entries []util.Entry
+
+	err := json.Unmarshal(out, &entries)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+
+	return entries
+}
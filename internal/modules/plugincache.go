@@ -0,0 +1,103 @@
+package modules
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCachedTerms limits the amount of outputs kept for plugins whose source depends on the term.
+const maxCachedTerms = 100
+
+// pluginCache keeps the output of a plugin's source per term. Outputs older than the ttl are still used, but fetched
+// again in the background, so slow sources don't hold up queries.
+type pluginCache struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	items map[string]*cachedOutput
+}
+
+type cachedOutput struct {
+	out        []byte
+	time       time.Time
+	refreshing bool
+}
+
+func newPluginCache(ttl time.Duration) *pluginCache {
+	return &pluginCache{
+		ttl:   ttl,
+		items: make(map[string]*cachedOutput),
+	}
+}
+
+// get returns the cached output for key, ok is false if there is none. If it's expired, fetch is run in the background.
+func (c *pluginCache) get(key string, fetch func() ([]byte, bool)) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	if time.Since(item.time) > c.ttl && !item.refreshing {
+		item.refreshing = true
+
+		go c.refresh(key, fetch)
+	}
+
+	return item.out, true
+}
+
+// expired reports whether key has no output or an expired one.
+func (c *pluginCache) expired(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.items[key]
+
+	return !ok || time.Since(item.time) > c.ttl
+}
+
+func (c *pluginCache) refresh(key string, fetch func() ([]byte, bool)) {
+	out, ok := fetch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, exists := c.items[key]
+
+	if !ok {
+		if exists {
+			item.refreshing = false
+		}
+
+		return
+	}
+
+	c.setLocked(key, out)
+}
+
+func (c *pluginCache) set(key string, out []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setLocked(key, out)
+}
+
+func (c *pluginCache) setLocked(key string, out []byte) {
+	if _, ok := c.items[key]; !ok && len(c.items) >= maxCachedTerms {
+		var oldest string
+		var oldestTime time.Time
+
+		for k, v := range c.items {
+			if oldestTime.IsZero() || v.time.Before(oldestTime) {
+				oldest = k
+				oldestTime = v.time
+			}
+		}
+
+		delete(c.items, oldest)
+	}
+
+	c.items[key] = &cachedOutput{out: out, time: time.Now()}
+}
@@ -2,22 +2,26 @@ package modules
 
 import (
 	"bufio"
-	"bytes"
-	"encoding/json"
+	"context"
 	"log"
 	"net/url"
 	"os/exec"
-	"strconv"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/abenz1267/walker/internal/config"
 	"github.com/abenz1267/walker/internal/util"
 )
 
+// streamInterval is the minimum time between two batches of a streaming plugin.
+const streamInterval = 50 * time.Millisecond
+
 type Plugin struct {
 	Config       config.Plugin
 	cachedOutput []byte
 	entries      []util.Entry
+	cache        *pluginCache
 }
 
 func (e *Plugin) General() *config.GeneralModule {
@@ -26,19 +30,28 @@ func (e *Plugin) General() *config.GeneralModule {
 
 func (e *Plugin) Refresh() {
 	e.Config.IsSetup = !e.Config.Refresh
+
+	// sources that don't depend on the term are fetched ahead of the next query
+	if e.cache != nil && !e.cachedByTerm() && e.cache.expired("") {
+		go e.getSrcOutput(context.Background(), e.Config.Src, false, "")
+	}
 }
 
 func (e *Plugin) Setup() bool {
 	e.Config.Separator = util.TransformSeparator(e.Config.Separator)
 
 	if e.Config.Parser == "" {
-		e.Config.Parser = "json"
+		e.Config.Parser = ParserJSON
 	}
 
 	if e.Config.KvSeparator == "" {
 		e.Config.KvSeparator = ";"
 	}
 
+	if e.Config.CacheTTL > 0 {
+		e.cache = newPluginCache(time.Duration(e.Config.CacheTTL) * time.Second)
+	}
+
 	return true
 }
 
@@ -55,14 +68,10 @@ func (e *Plugin) SetupData() {
 	if e.Config.SrcOnce != "" {
 		e.Config.Src = e.Config.SrcOnce
 
-		e.cachedOutput = e.getSrcOutput(e.Config.SrcOnce, false, "")
+		e.cachedOutput = e.getSrcOutput(context.Background(), e.Config.SrcOnce, false, "")
 
 		if e.Config.Cmd == "" {
-			if e.Config.Parser == "json" {
-				e.entries = e.parseJson(e.cachedOutput)
-			} else if e.Config.Parser == "kv" {
-				e.entries = e.parseKv(e.cachedOutput)
-			}
+			e.entries = e.parse(e.cachedOutput)
 
 			for k := range e.entries {
 				e.entries[k].Class = e.Config.Name
@@ -74,7 +83,11 @@ func (e *Plugin) SetupData() {
 	e.Config.HasInitialSetup = true
 }
 
-func (e Plugin) Entries(term string) []util.Entry {
+func (e Plugin) Entries(ctx context.Context, term string) []util.Entry {
+	return withPluginDefaults(e.Config, e.query(ctx, term))
+}
+
+func (e Plugin) query(ctx context.Context, term string) []util.Entry {
 	if e.Config.Entries != nil {
 		for k := range e.Config.Entries {
 			e.Config.Entries[k].ScoreFinal = 0
@@ -166,23 +179,7 @@ func (e Plugin) Entries(term string) []util.Entry {
 			return e.entries
 		}
 
-		cmd := exec.Command("sh", "-c", wrapWithPrefix(src))
-
-		if !hasExplicitTerm {
-			cmd.Stdin = strings.NewReader(term)
-		}
-
-		out, err := cmd.CombinedOutput()
-		if err != nil {
-			log.Println(err)
-			return entries
-		}
-
-		if e.Config.Parser == "json" {
-			entries = e.parseJson(out)
-		} else if e.Config.Parser == "kv" {
-			entries = e.parseKv(out)
-		}
+		entries = e.parse(e.getSrcOutput(ctx, src, hasExplicitTerm, term))
 
 		for k := range entries {
 			entries[k].Class = e.Config.Name
@@ -195,7 +192,7 @@ func (e Plugin) Entries(term string) []util.Entry {
 		if e.cachedOutput != nil {
 			out = e.cachedOutput
 		} else {
-			out = e.getSrcOutput(src, hasExplicitTerm, term)
+			out = e.getSrcOutput(ctx, src, hasExplicitTerm, term)
 		}
 
 		scanner := bufio.NewScanner(strings.NewReader(string(out)))
@@ -261,115 +258,160 @@ func (e Plugin) Entries(term string) []util.Entry {
 	return entries
 }
 
-func (e Plugin) parseKv(out []byte) []util.Entry {
-	var entries []util.Entry
+// StreamEntries pushes the entries of plugins with line based output (all parsers but json) as their lines arrive.
+// Other plugins deliver all entries at once.
+func (e Plugin) StreamEntries(ctx context.Context, term string, push func([]util.Entry)) {
+	parseLine := e.lineParser()
 
-	scanner := bufio.NewScanner(bytes.NewReader(out))
+	if e.Config.Entries != nil || e.Config.Output || e.Config.Cmd != "" || e.Config.SrcOnce != "" || parseLine == nil || e.Config.Src == "" || e.cache != nil {
+		push(e.Entries(ctx, term))
+		return
+	}
 
-	for scanner.Scan() {
-		pairs := strings.Split(scanner.Text(), e.Config.KvSeparator)
-
-		entry := util.Entry{}
-
-		for _, v := range pairs {
-			pair := strings.Split(v, "=")
-			switch {
-			case pair[0] == "path":
-				entry.Path = pair[1]
-			case pair[0] == "score_final":
-				score, err := strconv.ParseFloat(pair[1], 64)
-				if err != nil {
-					log.Println(err)
-					continue
-				}
+	emit := func(entries []util.Entry) {
+		push(withPluginDefaults(e.Config, entries))
+	}
 
-				entry.ScoreFinal = score
-			case pair[0] == "score_fuzzy":
-				score, err := strconv.ParseFloat(pair[1], 64)
-				if err != nil {
-					log.Println(err)
-					continue
-				}
+	src := e.Config.Src
+	hasExplicitTerm := strings.Contains(src, "%TERM%")
 
-				entry.ScoreFuzzy = score
-			case pair[0] == "recalculate_score":
-				entry.RecalculateScore, _ = strconv.ParseBool(pair[1])
-			case pair[0] == "label":
-				entry.Label = pair[1]
-			case pair[0] == "sub":
-				entry.Sub = pair[1]
-			case pair[0] == "exec":
-				entry.Exec = pair[1]
-			case pair[0] == "image":
-				entry.Image = pair[1]
-			case pair[0] == "icon":
-				entry.Icon = pair[1]
-			case pair[0] == "exec_alt":
-				entry.ExecAlt = pair[1]
-			case pair[0] == "class":
-				entry.Class = pair[1]
-			case pair[0] == "initial_class":
-				entry.InitialClass = pair[1]
-			case pair[0] == "matching":
-				mt, err := strconv.Atoi(pair[1])
-				if err != nil {
-					log.Println(err)
-					continue
-				}
+	if hasExplicitTerm {
+		src = strings.ReplaceAll(src, "%TERM%", term)
+	}
 
-				entry.Matching = util.MatchingType(mt)
-			case pair[0] == "match_fields":
-				entry.MatchFields, _ = strconv.Atoi(pair[1])
-			case pair[0] == "searchable":
-				entry.Searchable = pair[1]
-			case pair[0] == "categories":
-				entry.Categories = strings.Split(pair[1], ",")
-			case pair[0] == "terminal":
-				entry.Terminal, _ = strconv.ParseBool(pair[1])
-			case pair[0] == "prefer":
-				entry.Prefer, _ = strconv.ParseBool(pair[1])
-			case pair[0] == "drag_drop":
-				entry.DragDrop, _ = strconv.ParseBool(pair[1])
-			case pair[0] == "drag_drop_data":
-				entry.DragDropData = pair[1]
-			case pair[0] == "hide_text":
-				entry.HideText, _ = strconv.ParseBool(pair[1])
-			case pair[0] == "value":
-				entry.Value = pair[1]
-			}
+	cmd := e.command(ctx, src)
+
+	if !hasExplicitTerm {
+		cmd.Stdin = strings.NewReader(term)
+	}
+
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	batch := []util.Entry{}
+	last := time.Now()
+
+	scanner := bufio.NewScanner(out)
+
+	for scanner.Scan() {
+		entry, ok := parseLine(scanner.Text())
+		if !ok {
+			continue
 		}
 
-		if entry.Label != "" {
-			entries = append(entries, entry)
+		entry.Class = e.Config.Name
+		batch = append(batch, entry)
+
+		if time.Since(last) > streamInterval {
+			emit(batch)
+			batch = []util.Entry{}
+			last = time.Now()
 		}
 	}
 
-	return entries
+	if len(batch) > 0 {
+		emit(batch)
+	}
+
+	err = cmd.Wait()
+	if err != nil {
+		logUnlessCancelled(ctx, err)
+	}
 }
 
-func (e Plugin) parseJson(out []byte) []util.Entry {
-	var entries []util.Entry
+// command runs src in the plugin's directory, if it has one.
+func (e Plugin) command(ctx context.Context, src string) *exec.Cmd {
+	cmd := commandContext(ctx, "sh", "-c", wrapWithPrefix(src))
+	cmd.Dir = e.Config.Dir
 
-	err := json.Unmarshal(out, &entries)
-	if err != nil {
-		log.Println(err)
-		return nil
+	return cmd
+}
+
+// withPluginDefaults adds the actions declared by the plugin to its entries and runs them in the plugin's directory.
+// %VALUE% and %LABEL% in the actions' exec and value are replaced with the ones of the entry.
+func withPluginDefaults(cfg config.Plugin, entries []util.Entry) []util.Entry {
+	if len(cfg.Actions) == 0 && cfg.Dir == "" {
+		return entries
+	}
+
+	// entries can be cached by the plugin, so those aren't changed
+	entries = slices.Clone(entries)
+
+	for k := range entries {
+		if entries[k].Path == "" {
+			entries[k].Path = cfg.Dir
+		}
+
+		actions := slices.Clip(entries[k].Actions)
+
+		for _, v := range cfg.Actions {
+			r := strings.NewReplacer("%VALUE%", entries[k].Value, "%LABEL%", entries[k].Label)
+
+			v.Exec = r.Replace(v.Exec)
+			v.Value = r.Replace(v.Value)
+
+			actions = append(actions, v)
+		}
+
+		entries[k].Actions = actions
 	}
 
 	return entries
 }
 
-func (e Plugin) getSrcOutput(src string, hasExplicitTerm bool, term string) []byte {
-	cmd := exec.Command("sh", "-c", wrapWithPrefix(src))
+// cachedByTerm reports whether the output of src is cached per term, otherwise it's reused for all terms.
+func (e Plugin) cachedByTerm() bool {
+	return e.Config.CacheByTerm || strings.Contains(e.Config.Src, "%TERM%")
+}
 
-	if !hasExplicitTerm && term != "" {
-		cmd.Stdin = strings.NewReader(term)
+// getSrcOutput runs src, or takes its output from the cache if enabled.
+func (e Plugin) getSrcOutput(ctx context.Context, src string, hasExplicitTerm bool, term string) []byte {
+	run := func(ctx context.Context) ([]byte, bool) {
+		cmd := e.command(ctx, src)
+
+		if !hasExplicitTerm && term != "" {
+			cmd.Stdin = strings.NewReader(term)
+		}
+
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			logUnlessCancelled(ctx, err)
+			return nil, false
+		}
+
+		return out, true
 	}
 
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		log.Println(err)
-		return nil
+	if e.cache == nil {
+		out, _ := run(ctx)
+		return out
+	}
+
+	key := ""
+
+	if e.cachedByTerm() {
+		key = term
+	}
+
+	out, ok := e.cache.get(key, func() ([]byte, bool) {
+		return run(context.Background())
+	})
+	if ok {
+		return out
+	}
+
+	out, ok = run(ctx)
+	if ok {
+		e.cache.set(key, out)
 	}
 
 	return out
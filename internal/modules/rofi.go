@@ -0,0 +1,227 @@
+package modules
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/util"
+)
+
+const PluginKindRofi = "rofi"
+
+// ROFI_RETV values, see rofi-script(5).
+const (
+	rofiInitial  = 0
+	rofiSelected = 1
+	rofiCustom   = 2
+)
+
+// Menu is implemented by modules that show another level of entries after one was activated.
+type Menu interface {
+	InMenu() bool
+}
+
+// RofiPlugin runs rofi script modes. The script is called without arguments for the first rows, and again with the
+// selected row as argument and ROFI_RETV, ROFI_INFO and ROFI_DATA set. If it prints rows again, they replace the
+// current ones, otherwise walker closes. The prompt is used as placeholder, the message is shown as first entry.
+type RofiPlugin struct {
+	Config config.Plugin
+
+	mu          sync.Mutex
+	placeholder string
+	rows        []util.Entry
+	message     string
+	data        string
+	noCustom    bool
+	inMenu      bool
+}
+
+type rofiRow struct {
+	text string
+	info string
+	retv int
+}
+
+func (p *RofiPlugin) General() *config.GeneralModule {
+	return &p.Config.GeneralModule
+}
+
+func (p *RofiPlugin) Refresh() {
+	p.Config.IsSetup = false
+}
+
+func (p *RofiPlugin) Setup() bool {
+	p.placeholder = p.Config.Placeholder
+
+	return p.Config.Src != ""
+}
+
+func (p *RofiPlugin) SetupData() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.data = ""
+	p.inMenu = false
+
+	err := p.call(rofiRow{retv: rofiInitial})
+	if err != nil {
+		log.Printf("plugin %s: %s\n", p.Config.Name, err)
+	}
+
+	p.Config.IsSetup = true
+	p.Config.HasInitialSetup = true
+}
+
+// Cleanup starts over at the first level the next time.
+func (p *RofiPlugin) Cleanup() {
+	p.Config.IsSetup = false
+}
+
+func (p *RofiPlugin) InMenu() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.inMenu
+}
+
+func (p *RofiPlugin) Entries(ctx context.Context, term string) []util.Entry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entries := []util.Entry{}
+
+	if p.message != "" {
+		entries = append(entries, util.Entry{
+			Label:         p.message,
+			Class:         "message",
+			Matching:      util.AlwaysTop,
+			Nonselectable: true,
+		})
+	}
+
+	for _, v := range p.rows {
+		v.ScoreFinal = 0
+		v.ScoreFuzzy = 0
+
+		entries = append(entries, v)
+	}
+
+	if !p.noCustom && term != "" {
+		entries = append(entries, util.Entry{
+			Label:           term,
+			Class:           p.Config.Name,
+			Matching:        util.AlwaysBottom,
+			SpecialFunc:     p.SpecialFunc,
+			SpecialFuncArgs: []interface{}{rofiRow{text: term, retv: rofiCustom}},
+		})
+	}
+
+	return entries
+}
+
+func (p *RofiPlugin) SpecialFunc(args ...interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	err := p.call(args[0].(rofiRow))
+	if err != nil {
+		log.Printf("plugin %s: %s\n", p.Config.Name, err)
+	}
+}
+
+// call runs the script for the given row and replaces the rows with its output.
+func (p *RofiPlugin) call(row rofiRow) error {
+	src := wrapWithPrefix(p.Config.Src)
+
+	if row.retv != rofiInitial {
+		src = fmt.Sprintf("%s %s", src, shellQuote(row.text))
+	}
+
+	cmd := exec.Command("sh", "-c", src)
+	cmd.Dir = p.Config.Dir
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("ROFI_RETV=%d", row.retv),
+		fmt.Sprintf("ROFI_INFO=%s", row.info),
+		fmt.Sprintf("ROFI_DATA=%s", p.data),
+	)
+
+	out, err := cmd.Output()
+
+	p.rows = []util.Entry{}
+	p.message = ""
+	p.data = ""
+	p.noCustom = false
+	p.inMenu = false
+
+	if err != nil {
+		return err
+	}
+
+	p.Config.Placeholder = p.placeholder
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "\x00") {
+			p.setOption(strings.TrimPrefix(line, "\x00"))
+			continue
+		}
+
+		entry, ok := parseRofiLine(line)
+		if !ok {
+			continue
+		}
+
+		entry.Class = p.Config.Name
+		entry.Sub = p.Config.Name
+		entry.RecalculateScore = true
+
+		// the script gets the row as it printed it, the label might be its "display" option
+		text, opts := rofiOptions(line)
+
+		if opts["nonselectable"] == "true" {
+			entry.Nonselectable = true
+		} else {
+			entry.SpecialFunc = p.SpecialFunc
+			entry.SpecialFuncArgs = []interface{}{rofiRow{text: text, info: entry.Value, retv: rofiSelected}}
+		}
+
+		p.rows = append(p.rows, entry)
+	}
+
+	// the first call always shows its rows, later ones only continue the menu if they printed any
+	p.inMenu = row.retv != rofiInitial && len(p.rows) > 0
+
+	return nil
+}
+
+// setOption applies a mode option like "prompt\x1fChoose". Unsupported ones are ignored.
+func (p *RofiPlugin) setOption(line string) {
+	key, val, _ := strings.Cut(line, "\x1f")
+
+	switch key {
+	case "prompt":
+		p.Config.Placeholder = val
+	case "message":
+		p.message = val
+	case "data":
+		p.data = val
+	case "no-custom":
+		p.noCustom = val == "true"
+	}
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
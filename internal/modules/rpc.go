@@ -0,0 +1,392 @@
+package modules
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/util"
+)
+
+const PluginKindRPC = "rpc"
+
+const (
+	RPCMethodQuery    = "query"
+	RPCMethodActivate = "activate"
+	RPCMethodCleanup  = "cleanup"
+)
+
+// RPCPlugin keeps the plugin's process running and talks JSON-RPC 2.0 to it, one message per line on stdin and stdout.
+//
+//	-> {"jsonrpc":"2.0","id":1,"method":"query","params":{"term":"fi"}}
+//	<- {"jsonrpc":"2.0","id":1,"result":[{"label":"Firefox","exec":"firefox"}]}
+//
+// Entries without exec are handed back to the plugin with "activate" when selected, as are actions of type "func"
+// together with the action's name. "cleanup" is sent as notification when the window closes. The process is restarted
+// on the next request if it exited, it should quit once stdin is closed. Responses are matched to their request by id.
+// A cancelled request stops waiting and its response is dropped, a timed out one restarts the plugin.
+type RPCPlugin struct {
+	Config config.Plugin
+
+	// mu guards the process, its pending requests and the id of the last request.
+	mu   sync.Mutex
+	proc *rpcProcess
+	id   int
+}
+
+// rpcProcess is a running plugin, its responses are read by its own goroutine and handed to the pending requests.
+type rpcProcess struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	writeMu sync.Mutex
+	pending map[int]chan rpcResult
+}
+
+type rpcResult struct {
+	result json.RawMessage
+	err    error
+}
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int    `json:"id,omitempty"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcQuery struct {
+	Term string `json:"term"`
+}
+
+type rpcActivate struct {
+	Entry  rpcEntry `json:"entry"`
+	Term   string   `json:"term"`
+	Action string   `json:"action,omitempty"`
+}
+
+type rpcEntry struct {
+	Identifier string `json:"identifier"`
+	Label      string `json:"label"`
+	Sub        string `json:"sub,omitempty"`
+	Value      string `json:"value,omitempty"`
+}
+
+func (p *RPCPlugin) General() *config.GeneralModule {
+	return &p.Config.GeneralModule
+}
+
+func (p *RPCPlugin) Refresh() {
+	p.Config.IsSetup = !p.Config.Refresh
+}
+
+func (p *RPCPlugin) Setup() bool {
+	return p.Config.Src != ""
+}
+
+func (p *RPCPlugin) SetupData() {
+	p.mu.Lock()
+
+	err := p.start()
+	if err != nil {
+		log.Println(err)
+	}
+
+	p.mu.Unlock()
+
+	p.Config.IsSetup = true
+	p.Config.HasInitialSetup = true
+}
+
+func (p *RPCPlugin) Cleanup() {
+	p.mu.Lock()
+	proc := p.proc
+	p.mu.Unlock()
+
+	if proc == nil {
+		return
+	}
+
+	err := proc.send(rpcRequest{JSONRPC: "2.0", Method: RPCMethodCleanup})
+	if err != nil {
+		log.Println(err)
+		p.stop(proc)
+	}
+}
+
+// Stop ends the plugin's process, f.e. when the plugin is reloaded. It doesn't wait for pending requests.
+func (p *RPCPlugin) Stop() {
+	p.mu.Lock()
+	proc := p.proc
+	p.mu.Unlock()
+
+	if proc != nil {
+		p.stop(proc)
+	}
+}
+
+func (p *RPCPlugin) Entries(ctx context.Context, term string) []util.Entry {
+	entries := []util.Entry{}
+
+	err := p.call(ctx, RPCMethodQuery, rpcQuery{Term: term}, &entries)
+	if err != nil {
+		if ctx.Err() != nil {
+			return []util.Entry{}
+		}
+
+		log.Printf("plugin %s: %s\n", p.Config.Name, err)
+		return []util.Entry{}
+	}
+
+	entries = withPluginDefaults(p.Config, entries)
+
+	for k := range entries {
+		if entries[k].Class == "" {
+			entries[k].Class = p.Config.Name
+		}
+
+		entries[k].RecalculateScore = entries[k].RecalculateScore || p.Config.RecalculateScore
+
+		entry := rpcEntry{
+			Identifier: entries[k].Identifier(),
+			Label:      entries[k].Label,
+			Sub:        entries[k].Sub,
+			Value:      entries[k].Value,
+		}
+
+		if entries[k].Exec == "" {
+			entries[k].SpecialFunc = p.SpecialFunc
+			entries[k].SpecialFuncArgs = []interface{}{entry}
+		}
+
+		for a := range entries[k].Actions {
+			action := &entries[k].Actions[a]
+
+			if action.Type == "" {
+				action.Type = util.ActionFunc
+			}
+
+			if action.Type != util.ActionFunc {
+				continue
+			}
+
+			name := action.Name
+
+			action.Func = func(term string) {
+				p.activate(rpcActivate{Entry: entry, Term: term, Action: name})
+			}
+		}
+	}
+
+	return entries
+}
+
+func (p *RPCPlugin) SpecialFunc(args ...interface{}) {
+	params := rpcActivate{
+		Entry: args[0].(rpcEntry),
+	}
+
+	if len(args) > 1 {
+		params.Term, _ = args[len(args)-1].(string)
+	}
+
+	p.activate(params)
+}
+
+func (p *RPCPlugin) activate(params rpcActivate) {
+	err := p.call(context.Background(), RPCMethodActivate, params, nil)
+	if err != nil {
+		log.Printf("plugin %s: %s\n", p.Config.Name, err)
+	}
+}
+
+func (p *RPCPlugin) call(ctx context.Context, method string, params any, result any) error {
+	p.mu.Lock()
+
+	err := p.start()
+	if err != nil {
+		p.mu.Unlock()
+		return err
+	}
+
+	proc := p.proc
+
+	p.id++
+	id := p.id
+
+	done := make(chan rpcResult, 1)
+	proc.pending[id] = done
+
+	p.mu.Unlock()
+
+	err = proc.send(rpcRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		p.stop(proc)
+		return err
+	}
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return fmt.Errorf("%s failed: %w", method, res.err)
+		}
+
+		if result == nil || len(res.result) == 0 || string(res.result) == "null" {
+			return nil
+		}
+
+		return json.Unmarshal(res.result, result)
+	case <-ctx.Done():
+		// the response is dropped once it arrives
+		p.mu.Lock()
+		delete(proc.pending, id)
+		p.mu.Unlock()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			p.stop(proc)
+		}
+
+		return ctx.Err()
+	}
+}
+
+// read hands the responses of the process to the pending requests until the process exits.
+func (p *RPCPlugin) read(proc *rpcProcess, stdout io.Reader) {
+	r := bufio.NewReader(stdout)
+
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+
+		var res rpcResponse
+
+		err = json.Unmarshal(line, &res)
+		if err != nil {
+			log.Printf("plugin %s: invalid message: %s\n", p.Config.Name, err)
+			continue
+		}
+
+		p.mu.Lock()
+		done, ok := proc.pending[res.ID]
+		delete(proc.pending, res.ID)
+		p.mu.Unlock()
+
+		if !ok {
+			continue
+		}
+
+		if res.Error != nil {
+			done <- rpcResult{err: fmt.Errorf("%s (%d)", res.Error.Message, res.Error.Code)}
+			continue
+		}
+
+		done <- rpcResult{result: res.Result}
+	}
+
+	p.mu.Lock()
+
+	if p.proc == proc {
+		p.proc = nil
+	}
+
+	pending := proc.pending
+	proc.pending = nil
+
+	p.mu.Unlock()
+
+	for _, done := range pending {
+		done <- rpcResult{err: errors.New("plugin exited")}
+	}
+
+	proc.stdin.Close()
+	_ = proc.cmd.Wait()
+}
+
+func (r *rpcProcess) send(req rpcRequest) error {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	_, err = r.stdin.Write(append(b, '\n'))
+
+	return err
+}
+
+// start runs the plugin's process unless it's running, p.mu must be held.
+func (p *RPCPlugin) start() error {
+	if p.proc != nil {
+		return nil
+	}
+
+	cmd := exec.Command("sh", "-c", wrapWithPrefix(p.Config.Src))
+	cmd.Dir = p.Config.Dir
+	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return errors.Join(fmt.Errorf("plugin %s: couldn't start", p.Config.Name), err)
+	}
+
+	p.proc = &rpcProcess{
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int]chan rpcResult),
+	}
+
+	go p.read(p.proc, stdout)
+
+	return nil
+}
+
+// stop closes stdin and kills the process group of the plugin, it will be started again on the next request. The
+// process is waited for once its output is read.
+func (p *RPCPlugin) stop(proc *rpcProcess) {
+	p.mu.Lock()
+
+	if p.proc == proc {
+		p.proc = nil
+	}
+
+	p.mu.Unlock()
+
+	proc.stdin.Close()
+	_ = syscall.Kill(-proc.cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -2,6 +2,7 @@ package modules
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io/fs"
 	"log"
@@ -62,7 +63,7 @@ func (r *Runner) Refresh() {
 	r.config.IsSetup = !r.config.Refresh
 }
 
-func (r Runner) Entries(term string) []util.Entry {
+func (r Runner) Entries(ctx context.Context, term string) []util.Entry {
 	entries := []util.Entry{}
 
 	fields := strings.Fields(term)
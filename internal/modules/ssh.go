@@ -2,6 +2,7 @@ package modules
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -27,7 +28,7 @@ func (s *SSH) Refresh() {
 	s.config.IsSetup = !s.config.Refresh
 }
 
-func (s SSH) Entries(term string) []util.Entry {
+func (s SSH) Entries(ctx context.Context, term string) []util.Entry {
 	fields := strings.Fields(term)
 
 	cmd := "ssh"
@@ -94,6 +95,7 @@ func getConfigFileEntries(sshCfg string) []util.Entry {
 			fields := strings.Fields(text)
 
 			entries = append(entries, util.Entry{
+				ID:               fmt.Sprintf("ssh:%s", fields[1]),
 				Label:            fields[1],
 				Sub:              "SSH Config",
 				Exec:             fmt.Sprintf("ssh %s", fields[1]),
@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"context"
 	"slices"
 
 	"github.com/abenz1267/walker/internal/config"
@@ -17,7 +18,7 @@ func (s *Switcher) General() *config.GeneralModule {
 
 func (s Switcher) Cleanup() {}
 
-func (s Switcher) Entries(term string) []util.Entry {
+func (s Switcher) Entries(ctx context.Context, term string) []util.Entry {
 	entries := []util.Entry{}
 
 	for _, v := range config.Cfg.Available {
@@ -2,6 +2,7 @@ package symbols
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -26,7 +27,7 @@ func (e *Symbols) General() *config.GeneralModule {
 
 func (e Symbols) Cleanup() {}
 
-func (e Symbols) Entries(term string) []util.Entry {
+func (e Symbols) Entries(ctx context.Context, term string) []util.Entry {
 	return e.entries
 }
 
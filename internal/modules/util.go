@@ -1,8 +1,13 @@
 package modules
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"os/exec"
+	"syscall"
+	"time"
 
 	"github.com/abenz1267/walker/internal/config"
 	"github.com/abenz1267/walker/internal/util"
@@ -10,13 +15,41 @@ import (
 
 type Workable interface {
 	Cleanup()
-	Entries(term string) []util.Entry
+	Entries(ctx context.Context, term string) []util.Entry
 	General() *config.GeneralModule
 	Refresh()
 	Setup() bool
 	SetupData()
 }
 
+// Streaming is implemented by modules that deliver their entries in batches as they arrive, instead of all at once.
+type Streaming interface {
+	StreamEntries(ctx context.Context, term string, push func([]util.Entry))
+}
+
+// commandContext creates a command in its own process group, which is killed as a whole once ctx is done.
+func commandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+
+	return cmd
+}
+
+// logUnlessCancelled logs errors that aren't caused by a superseded or timed out query.
+func logUnlessCancelled(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	log.Println(err)
+}
+
 func Find(plugins []config.Plugin, name string) (config.Plugin, error) {
 	for _, v := range plugins {
 		if v.Name == name {
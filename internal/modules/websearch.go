@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -52,7 +53,7 @@ func (w *Websearch) Refresh() {
 	w.config.IsSetup = !w.config.Refresh
 }
 
-func (w Websearch) Entries(term string) []util.Entry {
+func (w Websearch) Entries(ctx context.Context, term string) []util.Entry {
 	entries := []util.Entry{}
 
 	path, _ := exec.LookPath("xdg-open")
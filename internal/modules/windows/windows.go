@@ -2,6 +2,7 @@ package windows
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io/fs"
 	"os"
@@ -105,7 +106,7 @@ func (w *Windows) GetIcons() {
 	}
 }
 
-func (w *Windows) Entries(term string) []util.Entry {
+func (w *Windows) Entries(ctx context.Context, term string) []util.Entry {
 	entries := []util.Entry{}
 
 	res := wlr.GetWindows()
@@ -2,6 +2,7 @@ package wlr
 
 import (
 	"log"
+	"slices"
 	"sync"
 
 	"github.com/neurlang/wayland/wl"
@@ -16,16 +17,54 @@ var (
 
 type windowmap map[wl.ProxyId]*Window
 
-var windows = make(windowmap)
+var (
+	windows = make(windowmap)
+
+	// windowsMu guards windows and their fields, they are written by the Wayland goroutine.
+	windowsMu sync.Mutex
+)
 
 var IsRunning = false
 
+// GetWindows returns a copy of the known windows.
 func GetWindows() windowmap {
-	return windows
+	windowsMu.Lock()
+	defer windowsMu.Unlock()
+
+	res := make(windowmap, len(windows))
+
+	for k, v := range windows {
+		w := *v
+		res[k] = &w
+	}
+
+	return res
+}
+
+// Focused returns the app id of the activated window.
+func Focused() string {
+	windowsMu.Lock()
+	defer windowsMu.Unlock()
+
+	for _, v := range windows {
+		if v.Activated {
+			return v.AppId
+		}
+	}
+
+	return ""
 }
 
 func Activate(id wl.ProxyId) {
-	err := windows[id].Toplevel.Activate(seat[len(seat)-1])
+	windowsMu.Lock()
+	w, ok := windows[id]
+	windowsMu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	err := w.Toplevel.Activate(seat[len(seat)-1])
 	if err != nil {
 		log.Fatalf("unable to activate toplevel: %v", err)
 	}
@@ -44,7 +83,8 @@ func StartWM(ac chan string, dc chan string) {
 
 	display, err = wl.Connect("")
 	if err != nil {
-		log.Fatalf("unable to connect to wayland server: %v", err)
+		log.Printf("unable to connect to wayland server: %v", err)
+		return
 	}
 
 	display.AddErrorHandler(displayErrorHandler{})
@@ -98,10 +138,10 @@ func (registryGlobalHandler) HandleRegistryGlobal(e wl.RegistryGlobalEvent) {
 }
 
 type Window struct {
-	mutex      sync.Mutex
 	Toplevel   *ZwlrForeignToplevelHandleV1
 	AppId      string
 	Title      string
+	Activated  bool
 	AddChan    chan string
 	DeleteChan chan string
 }
@@ -116,33 +156,54 @@ func (*Window) HandleZwlrForeignToplevelManagerV1Toplevel(e ZwlrForeignToplevelM
 	e.Toplevel.AddTitleHandler(handler)
 	e.Toplevel.AddAppIdHandler(handler)
 	e.Toplevel.AddClosedHandler(handler)
+	e.Toplevel.AddStateHandler(handler)
 
+	windowsMu.Lock()
 	windows[e.Toplevel.Id()] = &Window{Toplevel: e.Toplevel}
+	windowsMu.Unlock()
 }
 
 func (h *Window) HandleZwlrForeignToplevelHandleV1Closed(e ZwlrForeignToplevelHandleV1ClosedEvent) {
+	windowsMu.Lock()
+	appID := h.AppId
+	delete(windows, h.Toplevel.Id())
+	windowsMu.Unlock()
+
 	if h.DeleteChan != nil {
-		h.DeleteChan <- h.AppId
+		h.DeleteChan <- appID
 	}
-
-	h.mutex.Lock()
-	defer h.mutex.Unlock()
-	delete(windows, h.Toplevel.Id())
 }
 
 func (h *Window) HandleZwlrForeignToplevelHandleV1AppId(e ZwlrForeignToplevelHandleV1AppIdEvent) {
-	h.mutex.Lock()
-	defer h.mutex.Unlock()
-	windows[h.Toplevel.Id()].AppId = e.AppId
+	windowsMu.Lock()
+
+	if w, ok := windows[h.Toplevel.Id()]; ok {
+		w.AppId = e.AppId
+	}
+
 	h.AppId = e.AppId
 
+	windowsMu.Unlock()
+
 	if h.AddChan != nil {
 		h.AddChan <- e.AppId
 	}
 }
 
 func (h *Window) HandleZwlrForeignToplevelHandleV1Title(e ZwlrForeignToplevelHandleV1TitleEvent) {
-	h.mutex.Lock()
-	defer h.mutex.Unlock()
-	windows[h.Toplevel.Id()].Title = e.Title
+	windowsMu.Lock()
+	defer windowsMu.Unlock()
+
+	if w, ok := windows[h.Toplevel.Id()]; ok {
+		w.Title = e.Title
+	}
+}
+
+func (h *Window) HandleZwlrForeignToplevelHandleV1State(e ZwlrForeignToplevelHandleV1StateEvent) {
+	windowsMu.Lock()
+	defer windowsMu.Unlock()
+
+	if w, ok := windows[h.Toplevel.Id()]; ok {
+		w.Activated = slices.Contains(e.State, ZwlrForeignToplevelHandleV1StateActivated)
+	}
 }
@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
@@ -20,7 +21,7 @@ type XdphPicker struct {
 func (x *XdphPicker) Cleanup() {
 }
 
-func (x *XdphPicker) Entries(term string) []util.Entry {
+func (x *XdphPicker) Entries(ctx context.Context, term string) []util.Entry {
 	return x.entries
 }
 
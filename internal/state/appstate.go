@@ -17,9 +17,12 @@ type AppState struct {
 	ConfigError         error
 	IsDebug             bool
 	IsDmenu             bool
+	DmenuMulti          bool
 	Dmenu               *modules.Dmenu
 	DmenuSeparator      string
 	DmenuLabelColumn    int
+	DmenuJsonLines      bool
+	DmenuFormat         string
 	ExplicitConfig      string
 	ExplicitModules     []string
 	ExplicitPlaceholder string
@@ -0,0 +1,232 @@
+package store
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/abenz1267/walker/internal/util"
+)
+
+// Migration converts the gob encoded payload of a bucket to the next version.
+type Migration func(data []byte) ([]byte, error)
+
+// Bucket describes a piece of persisted data and how to upgrade it.
+// Version is the current schema version. Migrations are keyed by the version they upgrade from.
+// Legacy is the name of the gob file in the cache dir that held the data before the store existed,
+// its content is treated as version 1.
+// Discardable data is regenerated if it's missing, so older versions without a migration are removed quietly.
+type Bucket struct {
+	Name        string
+	Version     int
+	Legacy      string
+	Migrations  map[int]Migration
+	Discardable bool
+}
+
+type envelope struct {
+	Version int
+	Data    []byte
+}
+
+var mu sync.Mutex
+
+func Dir() string {
+	return filepath.Join(util.CacheDir(), "store")
+}
+
+func (b Bucket) file() string {
+	return filepath.Join(Dir(), fmt.Sprintf("%s.gob", b.Name))
+}
+
+// Load decodes the bucket into dest, migrating older data if needed. It reports whether data was found.
+// Malformed data is moved aside instead of being discarded.
+func Load(b Bucket, dest any) bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	version, data, isLegacy, ok := b.read()
+	if !ok {
+		return false
+	}
+
+	if version > b.Version {
+		log.Printf("store: %s has version %d, but only %d is supported.\n", b.Name, version, b.Version)
+		return false
+	}
+
+	migrated := version != b.Version
+
+	for version < b.Version {
+		migration, ok := b.Migrations[version]
+		if !ok && b.Discardable {
+			os.Remove(b.source(isLegacy))
+			return false
+		}
+
+		if !ok {
+			log.Printf("store: no migration for %s from version %d.\n", b.Name, version)
+			backup(b.source(isLegacy))
+			return false
+		}
+
+		var err error
+
+		data, err = migration(data)
+		if err != nil {
+			log.Printf("store: migrating %s from version %d failed: %s\n", b.Name, version, err)
+			backup(b.source(isLegacy))
+			return false
+		}
+
+		version++
+	}
+
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(dest)
+	if err != nil {
+		log.Printf("store: %s is malformed: %s\n", b.Name, err)
+		backup(b.source(isLegacy))
+		return false
+	}
+
+	if migrated || isLegacy {
+		err := write(b, data)
+		if err != nil {
+			log.Println(err)
+			return true
+		}
+
+		if isLegacy {
+			os.Remove(b.source(true))
+		}
+	}
+
+	return true
+}
+
+// Save encodes val as the current version of the bucket.
+func Save(b Bucket, val any) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var data bytes.Buffer
+
+	err := gob.NewEncoder(&data).Encode(val)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	err = write(b, data.Bytes())
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// Delete removes the bucket and its legacy file.
+func Delete(b Bucket) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	os.Remove(b.file())
+
+	if b.Legacy != "" {
+		os.Remove(b.source(true))
+	}
+}
+
+// Convert creates a migration that decodes the payload as From and encodes the result of fn.
+func Convert[From, To any](fn func(From) To) Migration {
+	return func(data []byte) ([]byte, error) {
+		var from From
+
+		err := gob.NewDecoder(bytes.NewReader(data)).Decode(&from)
+		if err != nil {
+			return nil, err
+		}
+
+		var res bytes.Buffer
+
+		err = gob.NewEncoder(&res).Encode(fn(from))
+		if err != nil {
+			return nil, err
+		}
+
+		return res.Bytes(), nil
+	}
+}
+
+func (b Bucket) source(isLegacy bool) string {
+	if isLegacy {
+		return filepath.Join(util.CacheDir(), b.Legacy)
+	}
+
+	return b.file()
+}
+
+func (b Bucket) read() (int, []byte, bool, bool) {
+	raw, err := os.ReadFile(b.file())
+	if err == nil {
+		var env envelope
+
+		err = gob.NewDecoder(bytes.NewReader(raw)).Decode(&env)
+		if err != nil {
+			log.Printf("store: %s is malformed: %s\n", b.Name, err)
+			backup(b.file())
+			return 0, nil, false, false
+		}
+
+		return env.Version, env.Data, false, true
+	}
+
+	if b.Legacy == "" {
+		return 0, nil, false, false
+	}
+
+	raw, err = os.ReadFile(b.source(true))
+	if err != nil {
+		return 0, nil, false, false
+	}
+
+	return 1, raw, true, true
+}
+
+func write(b Bucket, data []byte) error {
+	var raw bytes.Buffer
+
+	err := gob.NewEncoder(&raw).Encode(envelope{Version: b.Version, Data: data})
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(Dir(), 0755)
+	if err != nil {
+		return err
+	}
+
+	tmp := fmt.Sprintf("%s.tmp", b.file())
+
+	err = os.WriteFile(tmp, raw.Bytes(), 0o600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, b.file())
+}
+
+// backup moves a file that couldn't be read out of the way, so it can be recovered manually.
+func backup(file string) {
+	dest := fmt.Sprintf("%s.bak", file)
+
+	err := os.Rename(file, dest)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	log.Printf("store: moved %s to %s.\n", file, dest)
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOutdated(t *testing.T) {
+	tests := []struct {
+		name        string
+		discardable bool
+		backup      bool
+	}{
+		{"backed up", false, true},
+		{"discarded", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("XDG_CACHE_HOME", dir)
+
+			b := Bucket{Name: "test", Version: 2, Legacy: "test.gob", Discardable: tt.discardable}
+
+			legacy := filepath.Join(dir, "walker", b.Legacy)
+
+			err := os.MkdirAll(filepath.Dir(legacy), 0o755)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = os.WriteFile(legacy, []byte("old"), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var dest string
+
+			if Load(b, &dest) {
+				t.Fatal("outdated data was loaded")
+			}
+
+			if _, err := os.Stat(legacy); err == nil {
+				t.Error("outdated data wasn't moved")
+			}
+
+			if _, err := os.Stat(legacy + ".bak"); (err == nil) != tt.backup {
+				t.Errorf("got backup %v, want %v", err == nil, tt.backup)
+			}
+		})
+	}
+}
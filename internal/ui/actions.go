@@ -0,0 +1,203 @@
+package ui
+
+import (
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/history"
+	"github.com/abenz1267/walker/internal/modules"
+	"github.com/abenz1267/walker/internal/util"
+	"github.com/diamondburned/gotk4/pkg/core/gioutil"
+	"github.com/diamondburned/gotk4/pkg/gdk/v4"
+)
+
+var (
+	actionsOpen     bool
+	actionsPrevious []util.Entry
+
+	// actionsEntry is the entry the panel was opened for, actionsList its actions in the order they are listed.
+	actionsEntry util.Entry
+	actionsList  []util.Action
+)
+
+// toggleActions replaces the list with the actions of the selected entry. The window gets the "actions" class while they are shown.
+func toggleActions() bool {
+	if actionsOpen {
+		return closeActions()
+	}
+
+	if isAi || appstate.IsDmenu || common.items.NItems() == 0 {
+		return false
+	}
+
+	entry := gioutil.ObjectValue[util.Entry](common.items.Item(common.selection.Selected()))
+
+	if entry.Module == config.Cfg.Builtins.AI.Name || entry.Sub == "Walker" || entry.Sub == "switcher" {
+		return false
+	}
+
+	actions := entry.AllActions()
+
+	if len(actions) == 0 {
+		return false
+	}
+
+	stopQuery()
+
+	actionsPrevious = []util.Entry{}
+
+	for i := uint(0); i < common.items.NItems(); i++ {
+		actionsPrevious = append(actionsPrevious, gioutil.ObjectValue[util.Entry](common.items.Item(i)))
+	}
+
+	items := []util.Entry{}
+
+	for _, action := range actions {
+		items = append(items, util.Entry{
+			Label:  action.Label,
+			Sub:    action.Key,
+			Icon:   entry.Icon,
+			Class:  "action",
+			Module: entry.Module,
+		})
+	}
+
+	actionsEntry = entry
+	actionsList = actions
+
+	common.items.Splice(0, int(common.items.NItems()), items...)
+	common.selection.SetSelected(0)
+
+	actionsOpen = true
+	elements.appwin.AddCSSClass("actions")
+
+	return true
+}
+
+// closeActions restores the list the action panel was opened from.
+func closeActions() bool {
+	if !actionsOpen {
+		return false
+	}
+
+	hideActions()
+	common.items.Splice(0, int(common.items.NItems()), actionsPrevious...)
+	actionsPrevious = nil
+
+	return true
+}
+
+// hideActions leaves the action panel without restoring the list, f.e. because a new query replaces it.
+func hideActions() {
+	if !actionsOpen {
+		return
+	}
+
+	actionsOpen = false
+	actionsList = nil
+	elements.appwin.RemoveCSSClass("actions")
+}
+
+// activateAction runs the selected action of the panel, it stays open if the action or the activation asks for it.
+func activateAction(keepOpen bool) {
+	selected := int(common.selection.Selected())
+
+	if selected >= len(actionsList) {
+		return
+	}
+
+	action := actionsList[selected]
+
+	executeEvent(config.EventActivate, actionsEntry.Label)
+
+	runAction(actionsEntry, findModule(actionsEntry.Module, toUse, explicits), action, elements.input.Text(), usageContext)
+	closeAfterActivation(keepOpen || action.KeepOpen, false)
+}
+
+// runActionKey runs the action of the selected entry that is bound to the pressed key.
+func runActionKey(key int, modifier gdk.ModifierType) bool {
+	if isAi || appstate.IsDmenu || actionsOpen || common.items.NItems() == 0 {
+		return false
+	}
+
+	entry := gioutil.ObjectValue[util.Entry](common.items.Item(common.selection.Selected()))
+
+	for _, action := range entry.Actions {
+		if action.Key == "" {
+			continue
+		}
+
+		k, m := parseBind(action.Key)
+
+		if k != key || m != modifier {
+			continue
+		}
+
+		executeEvent(config.EventActivate, entry.Label)
+
+		runAction(entry, findModule(entry.Module, toUse, explicits), action, elements.input.Text(), usageContext)
+		closeAfterActivation(action.KeepOpen, false)
+
+		return true
+	}
+
+	return false
+}
+
+// runAction does what the action describes and records the usage of the entry in the given context.
+func runAction(entry util.Entry, module modules.Workable, action util.Action, term string, use history.Use) {
+	switch action.Type {
+	case util.ActionExec, util.ActionTerminal:
+		// run_alt runs ExecAlt like the alternative activation, so PipedAlt is sent as well
+		alt := action.Name == util.ActionRunAlt
+
+		if action.Exec != "" && !alt {
+			entry.Exec = action.Exec
+		}
+
+		entry.Terminal = entry.Terminal || action.Type == util.ActionTerminal
+
+		err := runEntry(entry, module, alt, term, use)
+		if err != nil {
+			log.Println(err)
+		}
+
+		return
+	case util.ActionCopy:
+		cmd := exec.Command("wl-copy")
+		cmd.Stdin = strings.NewReader(action.Value)
+
+		err := cmd.Run()
+		if err != nil {
+			log.Println(err)
+			return
+		}
+	case util.ActionOpen, util.ActionReveal:
+		target := action.Value
+
+		if action.Type == util.ActionReveal {
+			target = filepath.Dir(target)
+		}
+
+		err := exec.Command("xdg-open", target).Start()
+		if err != nil {
+			log.Println(err)
+			return
+		}
+	case util.ActionFunc:
+		if action.Func == nil {
+			log.Printf("action %s of %s can't be run\n", action.Name, entry.Label)
+			return
+		}
+
+		action.Func(term)
+	default:
+		log.Printf("unknown action type '%s'\n", action.Type)
+		return
+	}
+
+	saveUsage(entry, module, term, use)
+}
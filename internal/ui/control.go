@@ -0,0 +1,357 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/engine"
+	"github.com/abenz1267/walker/internal/history"
+	"github.com/abenz1267/walker/internal/modules"
+	"github.com/abenz1267/walker/internal/util"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+)
+
+var ControlSocketAddr = filepath.Join(util.TmpDir(), "walker-control.sock")
+
+const (
+	ControlActionModules  = "modules"
+	ControlActionQuery    = "query"
+	ControlActionActivate = "activate"
+	ControlActionPreview  = "preview"
+	ControlActionImport   = "import"
+)
+
+type ControlRequest struct {
+	Action     string   `json:"action"`
+	Modules    []string `json:"modules,omitempty"`
+	Term       string   `json:"term,omitempty"`
+	Identifier string   `json:"identifier,omitempty"`
+	Alt        bool     `json:"alt,omitempty"`
+
+	// EntryAction is the name of the entry's action to run instead of activating it.
+	EntryAction string `json:"entry_action,omitempty"`
+
+	// History is the export merged into the service's history by the import action.
+	History *history.Export `json:"history,omitempty"`
+}
+
+type ControlResponse struct {
+	Entries []engine.Result `json:"entries,omitempty"`
+	Preview string          `json:"preview,omitempty"`
+	Modules []ControlModule `json:"modules,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// controlTimeout bounds queries and previews of control requests, modules don't have a timeout by default.
+const controlTimeout = 5 * time.Second
+
+var (
+	// controlResults are the entries of the last query, activate and preview pick their entry from them.
+	controlResults   []util.Entry
+	controlResultsMu sync.Mutex
+)
+
+type ControlModule struct {
+	Name        string `json:"name"`
+	Prefix      string `json:"prefix,omitempty"`
+	Placeholder string `json:"placeholder,omitempty"`
+	Hidden      bool   `json:"hidden,omitempty"`
+}
+
+// ListenControl serves queries against the loaded modules without opening the window.
+// Every connection carries a single JSON encoded ControlRequest and gets a single ControlResponse back. Activate and
+// preview pick their entry from the results of the last query.
+func ListenControl() {
+	os.Remove(ControlSocketAddr)
+
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: ControlSocketAddr})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer l.Close()
+
+	for {
+		conn, err := l.AcceptUnix()
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		go handleControl(conn)
+	}
+}
+
+func handleControl(conn net.Conn) {
+	defer conn.Close()
+
+	res := ControlResponse{}
+
+	var req ControlRequest
+
+	err := json.NewDecoder(conn).Decode(&req)
+	if err != nil {
+		res.Error = err.Error()
+	} else {
+		res, err = control(req)
+		if err != nil {
+			res.Error = err.Error()
+		}
+	}
+
+	err = json.NewEncoder(conn).Encode(res)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// SendControl sends a request to the service, running is false if no service is listening.
+func SendControl(req ControlRequest) (res ControlResponse, running bool, err error) {
+	conn, err := net.Dial("unix", ControlSocketAddr)
+	if err != nil {
+		return res, false, nil
+	}
+	defer conn.Close()
+
+	err = json.NewEncoder(conn).Encode(req)
+	if err != nil {
+		return res, true, err
+	}
+
+	err = json.NewDecoder(conn).Decode(&res)
+	if err != nil {
+		return res, true, err
+	}
+
+	if res.Error != "" {
+		return res, true, errors.New(res.Error)
+	}
+
+	return res, true, nil
+}
+
+// onMainLoop runs fn on the main loop and waits for it. The modules, the history and the commands are shared with the
+// window, so they are only touched there.
+func onMainLoop(fn func()) {
+	done := make(chan struct{})
+
+	glib.IdleAdd(func() {
+		fn()
+		close(done)
+	})
+
+	<-done
+}
+
+func control(req ControlRequest) (ControlResponse, error) {
+	res := ControlResponse{}
+
+	switch req.Action {
+	case ControlActionImport:
+		if req.History == nil {
+			return res, errors.New("no history given")
+		}
+
+		onMainLoop(func() {
+			if hstry == nil {
+				hstry = history.Get()
+			}
+
+			history.Import(hstry, *req.History)
+		})
+	case ControlActionModules:
+		var p []modules.Workable
+		var err error
+
+		onMainLoop(func() {
+			p, err = controlModules(req.Modules)
+		})
+
+		if err != nil {
+			return res, err
+		}
+
+		for _, v := range p {
+			g := v.General()
+
+			res.Modules = append(res.Modules, ControlModule{
+				Name:        g.Name,
+				Prefix:      g.Prefix,
+				Placeholder: g.Placeholder,
+				Hidden:      g.Hidden,
+			})
+		}
+	case ControlActionQuery:
+		var q engine.Query
+		var err error
+
+		onMainLoop(func() {
+			var p []modules.Workable
+
+			p, err = controlModules(req.Modules)
+			q = controlQuery(req.Term, p)
+		})
+
+		if err != nil {
+			return res, err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+		defer cancel()
+
+		// modules that didn't finish in time are left out
+		var entries []util.Entry
+
+		engine.Stream(ctx, q, func(b engine.Batch) {
+			entries = b.Entries
+		})
+
+		controlResultsMu.Lock()
+		controlResults = entries
+		controlResultsMu.Unlock()
+
+		for _, v := range entries {
+			res.Entries = append(res.Entries, engine.ToResult(v))
+		}
+	case ControlActionActivate:
+		entry, err := controlResult(req.Identifier)
+		if err != nil {
+			return res, err
+		}
+
+		onMainLoop(func() {
+			err = activateHeadless(entry, req)
+		})
+
+		return res, err
+	case ControlActionPreview:
+		entry, err := controlResult(req.Identifier)
+		if err != nil {
+			return res, err
+		}
+
+		var module modules.Workable
+
+		onMainLoop(func() {
+			module = findModule(entry.Module, available)
+		})
+
+		previewer, ok := module.(modules.Previewer)
+		if !ok {
+			return res, modules.ErrNotSupported
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+		defer cancel()
+
+		res.Preview, err = previewer.Preview(ctx, entry)
+
+		return res, err
+	default:
+		return res, fmt.Errorf("unknown action: %s", req.Action)
+	}
+
+	return res, nil
+}
+
+// controlResult returns the entry with the given identifier from the results of the last query.
+func controlResult(identifier string) (util.Entry, error) {
+	if identifier == "" {
+		return util.Entry{}, errors.New("no identifier given")
+	}
+
+	controlResultsMu.Lock()
+	defer controlResultsMu.Unlock()
+
+	for _, v := range controlResults {
+		if v.Identifier() == identifier {
+			return v, nil
+		}
+	}
+
+	return util.Entry{}, fmt.Errorf("entry not found in the last query: %s", identifier)
+}
+
+// controlModules returns the requested modules, setting up the available ones if no window has done so yet.
+func controlModules(names []string) ([]modules.Workable, error) {
+	if len(available) == 0 {
+		if hstry == nil {
+			hstry = history.Get()
+		}
+
+		setAvailables()
+		engine.PrepareBlacklists(available)
+	}
+
+	if len(names) == 0 {
+		return available, nil
+	}
+
+	p := []modules.Workable{}
+
+	for _, v := range names {
+		m := findModule(v, available)
+		if m == nil {
+			return nil, fmt.Errorf("module not found: %s", v)
+		}
+
+		p = append(p, m)
+	}
+
+	return p, nil
+}
+
+func controlQuery(text string, p []modules.Workable) engine.Query {
+	return engine.Query{
+		Text:    text,
+		Modules: p,
+		History: hstry,
+		Context: engine.CurrentContext(),
+		Debug:   appstate.IsDebug,
+	}
+}
+
+func activateHeadless(entry util.Entry, req ControlRequest) error {
+	module := findModule(entry.Module, available)
+
+	switch {
+	case module != nil && module.General().Name == config.Cfg.Builtins.AI.Name:
+		return errors.New("ai entries can only be activated in the window")
+	case req.EntryAction != "":
+		action, ok := entry.FindAction(req.EntryAction)
+		if !ok {
+			return fmt.Errorf("entry has no action '%s'", req.EntryAction)
+		}
+
+		runAction(entry, module, action, req.Term, engine.CurrentContext())
+	case entry.Exec == "" && entry.SpecialFunc == nil && len(entry.Actions) > 0:
+		runAction(entry, module, entry.Actions[0], req.Term, engine.CurrentContext())
+	case entry.SpecialFunc != nil:
+		args := slices.Clone(entry.SpecialFuncArgs)
+		args = append(args, req.Term)
+
+		entry.SpecialFunc(args...)
+	case entry.Sub == "Walker":
+		if commands == nil {
+			setupCommands()
+		}
+
+		commands[entry.Exec]()
+	case entry.Sub == "switcher":
+		return errors.New("switcher entries can only be activated in the window")
+	default:
+		return runEntry(entry, module, req.Alt, req.Term, engine.CurrentContext())
+	}
+
+	return nil
+}
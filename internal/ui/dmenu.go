@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/modules"
+	"github.com/abenz1267/walker/internal/util"
+	"github.com/diamondburned/gotk4/pkg/core/gioutil"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+)
+
+var (
+	// marked holds the entries marked in multi select dmenu mode, in the order they were marked.
+	marked []util.Entry
+
+	// dmenuExitCode is the exit code of the client, set by the custom keybinds.
+	dmenuExitCode int
+
+	// dmenuQueue holds the invocations waiting for the shown dmenu session to end, dmenuBusy is set while one is shown
+	// and dmenuEnding while it's being ended.
+	dmenuQueueMu sync.Mutex
+	dmenuQueue   []queuedDmenu
+	dmenuBusy    bool
+	dmenuEnding  bool
+)
+
+func isMarked(entry util.Entry) bool {
+	return slices.ContainsFunc(marked, func(e util.Entry) bool {
+		return e.Index == entry.Index
+	})
+}
+
+// toggleMark marks or unmarks the selected entry and moves on to the next one.
+func toggleMark() bool {
+	if !appstate.IsDmenu || !appstate.DmenuMulti || common.items.NItems() == 0 {
+		return false
+	}
+
+	pos := common.selection.Selected()
+	entry := gioutil.ObjectValue[util.Entry](common.items.Item(pos))
+
+	if entry.Nonselectable {
+		return true
+	}
+
+	if isMarked(entry) {
+		marked = slices.DeleteFunc(marked, func(e util.Entry) bool {
+			return e.Index == entry.Index
+		})
+	} else {
+		marked = append(marked, entry)
+	}
+
+	// replacing the item binds it again, so the "marked" class is updated
+	common.items.Splice(int(pos), 1, entry)
+	common.selection.SetSelected(pos)
+
+	selectNext()
+
+	return true
+}
+
+// markedResult returns the marked entries one per line, ok is false if none are marked.
+func markedResult(alt bool) (string, bool) {
+	if len(marked) == 0 {
+		return "", false
+	}
+
+	res := []string{}
+
+	for _, v := range marked {
+		selection := v.Exec
+
+		if alt && v.ExecAlt != "" {
+			selection = v.ExecAlt
+		}
+
+		res = append(res, dmenuOutput(selection, v.Index))
+	}
+
+	return strings.Join(res, "\n"), true
+}
+
+// dmenuOutput formats the selection as set with "--format".
+func dmenuOutput(selection string, index int) string {
+	d, ok := findModule(config.Cfg.Builtins.Dmenu.Name, toUse, explicits).(*modules.Dmenu)
+	if !ok {
+		return selection
+	}
+
+	return d.Output(selection, index, elements.input.Text())
+}
+
+// dmenuCustom returns the handler for the n-th custom keybind. It selects like enter, but the client exits with its own
+// code, starting at modules.DmenuCustom.
+func dmenuCustom(n int) func() bool {
+	return func() bool {
+		if !appstate.IsDmenu {
+			return false
+		}
+
+		dmenuExitCode = modules.DmenuCustom + n
+		defer func() {
+			dmenuExitCode = 0
+		}()
+
+		return activate(false, false)
+	}
+}
+
+// queuedDmenu is a held back dmenu invocation, drop is called instead of run if its client went away meanwhile.
+type queuedDmenu struct {
+	session string
+	run     func()
+	drop    func()
+}
+
+// QueueDmenu holds back a dmenu invocation while another session is shown and runs it once that one ended. It returns
+// false if no session is shown, run isn't called then and the invocation can be handled right away.
+func QueueDmenu(session string, run, drop func()) bool {
+	dmenuQueueMu.Lock()
+	defer dmenuQueueMu.Unlock()
+
+	if !dmenuBusy {
+		dmenuBusy = true
+		return false
+	}
+
+	dmenuQueue = append(dmenuQueue, queuedDmenu{session: session, run: run, drop: drop})
+
+	return true
+}
+
+// EndDmenuSession cancels the shown session unless it got a reply, then shows the next queued one whose client is still
+// there. It runs after the activation that closed the window, so the reply isn't cancelled. The window can be closed
+// twice by one activation, the session is only ended once.
+func EndDmenuSession() {
+	dmenuQueueMu.Lock()
+	defer dmenuQueueMu.Unlock()
+
+	if dmenuEnding {
+		return
+	}
+
+	dmenuEnding = true
+
+	glib.IdleAdd(func() {
+		if appstate.Dmenu != nil {
+			appstate.Dmenu.Cancel()
+		}
+
+		dmenuQueueMu.Lock()
+
+		dmenuEnding = false
+
+		for len(dmenuQueue) > 0 {
+			next := dmenuQueue[0]
+			dmenuQueue = dmenuQueue[1:]
+
+			if appstate.Dmenu != nil && appstate.Dmenu.HungUp(next.session) {
+				appstate.Dmenu.Drop(next.session)
+				next.drop()
+
+				continue
+			}
+
+			dmenuQueueMu.Unlock()
+
+			next.run()
+
+			return
+		}
+
+		dmenuBusy = false
+		dmenuQueueMu.Unlock()
+	})
+}
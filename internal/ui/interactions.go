@@ -2,6 +2,7 @@ package ui
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -14,9 +15,12 @@ import (
 	"time"
 
 	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/engine"
 	"github.com/abenz1267/walker/internal/history"
 	"github.com/abenz1267/walker/internal/modules"
+	"github.com/abenz1267/walker/internal/modules/clipboard"
 	"github.com/abenz1267/walker/internal/state"
+	"github.com/abenz1267/walker/internal/store"
 	"github.com/abenz1267/walker/internal/util"
 	"github.com/diamondburned/gotk4/pkg/core/gioutil"
 	"github.com/diamondburned/gotk4/pkg/gdk/v4"
@@ -40,20 +44,33 @@ var (
 func setupCommands() {
 	commands = make(map[string]func() bool)
 	commands["resethistory"] = func() bool {
-		os.Remove(filepath.Join(util.CacheDir(), history.HistoryName))
+		store.Delete(history.HistoryBucket)
 		hstry = history.Get()
+		syncModules()
 		return true
 	}
 	commands["clearapplicationscache"] = func() bool {
-		os.Remove(filepath.Join(util.CacheDir(), "applications.json"))
+		store.Delete(modules.ApplicationsBucket)
 		return true
 	}
 	commands["clearclipboard"] = func() bool {
-		os.Remove(filepath.Join(util.CacheDir(), "clipboard.gob"))
+		store.Delete(clipboard.ClipboardBucket)
 		return true
 	}
 	commands["cleartypeaheadcache"] = func() bool {
-		os.Remove(filepath.Join(util.CacheDir(), "inputhistory_0.7.6.gob"))
+		store.Delete(history.InputHistoryBucket)
+		return true
+	}
+	commands["reloadplugins"] = func() bool {
+		config.LoadPlugins()
+
+		available = engine.ReloadPlugins(available)
+		engine.PrepareBlacklists(available)
+
+		if appstate.HasUI {
+			glib.IdleAdd(setupModules)
+		}
+
 		return true
 	}
 	commands["adjusttheme"] = func() bool {
@@ -76,6 +93,37 @@ func setupCommands() {
 
 var lastQuery = ""
 
+var (
+	cancelQuery   context.CancelFunc
+	cancelQueryMu sync.Mutex
+)
+
+// newQueryContext cancels the running query, so its modules stop and its results are dropped.
+func newQueryContext() context.Context {
+	cancelQueryMu.Lock()
+	defer cancelQueryMu.Unlock()
+
+	if cancelQuery != nil {
+		cancelQuery()
+	}
+
+	var ctx context.Context
+
+	ctx, cancelQuery = context.WithCancel(context.Background())
+
+	return ctx
+}
+
+func stopQuery() {
+	cancelQueryMu.Lock()
+	defer cancelQueryMu.Unlock()
+
+	if cancelQuery != nil {
+		cancelQuery()
+		cancelQuery = nil
+	}
+}
+
 func setupInteractions(appstate *state.AppState) {
 	go setupCommands()
 	parseKeybinds()
@@ -280,6 +328,10 @@ func handleGlobalKeysPressed(val uint, code uint, modifier gdk.ModifierType) boo
 				val = gdk.KEY_Tab
 			}
 
+			if runActionKey(int(val), modifier) {
+				return true
+			}
+
 			hasBind := binds.execute(int(val), modifier)
 
 			if hasBind {
@@ -323,9 +375,26 @@ func activateItem(keepOpen, alt bool) {
 
 	entry := gioutil.ObjectValue[util.Entry](common.items.Item(common.selection.Selected()))
 
+	if entry.Nonselectable {
+		return
+	}
+
+	if actionsOpen {
+		activateAction(keepOpen)
+		return
+	}
+
+	// entries without a command run their first action
+	hasDefaultAction := entry.Exec == "" && entry.SpecialFunc == nil && len(entry.Actions) > 0 && !appstate.IsDmenu
+
+	if hasDefaultAction && entry.Actions[0].KeepOpen {
+		keepOpen = true
+	}
+
 	executeEvent(config.EventActivate, entry.Label)
 
-	if !keepOpen && entry.Sub != "Walker" && entry.Sub != "switcher" && config.Cfg.IsService && entry.SpecialFunc == nil {
+	// dmenu closes once the result is sent, quit resets the state it's computed from
+	if !keepOpen && entry.Sub != "Walker" && entry.Sub != "switcher" && config.Cfg.IsService && entry.SpecialFunc == nil && !appstate.IsDmenu {
 		go quit(true)
 	}
 
@@ -352,26 +421,38 @@ func activateItem(keepOpen, alt bool) {
 			})
 		} else {
 			entry.SpecialFunc(args...)
+
+			if m, ok := module.(modules.Menu); ok && m.InMenu() {
+				showMenu(module)
+				return
+			}
+
 			closeAfterActivation(keepOpen, selectNext)
 		}
 
 		return
 	}
 
-	toRun := entry.Exec
+	if hasDefaultAction {
+		runAction(entry, module, entry.Actions[0], elements.input.Text(), usageContext)
+		closeAfterActivation(keepOpen, selectNext)
+		return
+	}
 
-	forceTerminal := false
+	if appstate.IsDmenu {
+		toRun := entry.Exec
 
-	if alt {
-		if entry.ExecAlt != "" {
+		if alt && entry.ExecAlt != "" {
 			toRun = entry.ExecAlt
-		} else {
-			forceTerminal = true
 		}
-	}
 
-	if appstate.IsDmenu {
-		handleDmenuResult(toRun)
+		toRun = dmenuOutput(toRun, entry.Index)
+
+		if res, ok := markedResult(alt); ok {
+			toRun = res
+		}
+
+		handleDmenuResult(dmenuExitCode, toRun)
 		closeAfterActivation(keepOpen, selectNext)
 		return
 	}
@@ -391,6 +472,28 @@ func activateItem(keepOpen, alt bool) {
 		return
 	}
 
+	err := runEntry(entry, module, alt, elements.input.Text(), usageContext)
+	if err != nil {
+		log.Println(err)
+	}
+
+	closeAfterActivation(keepOpen, selectNext)
+}
+
+// runEntry executes the entry's command, wrapped in a terminal if needed, and records its usage in the given context.
+func runEntry(entry util.Entry, module modules.Workable, alt bool, input string, use history.Use) error {
+	toRun := entry.Exec
+
+	forceTerminal := false
+
+	if alt {
+		if entry.ExecAlt != "" {
+			toRun = entry.ExecAlt
+		} else {
+			forceTerminal = true
+		}
+	}
+
 	if entry.Terminal || forceTerminal {
 		if config.Cfg.TerminalTitleFlag != "" || entry.TerminalTitleFlag != "" {
 			flag := config.Cfg.TerminalTitleFlag
@@ -405,11 +508,8 @@ func activateItem(keepOpen, alt bool) {
 		}
 	}
 
-	input := elements.input.Text()
-
 	if strings.Contains(input, config.Cfg.Search.ArgumentDelimiter) {
 		split := strings.Split(input, config.Cfg.Search.ArgumentDelimiter)
-		input = split[0]
 		toRun = fmt.Sprintf("%s %s", toRun, split[1])
 	}
 
@@ -435,22 +535,38 @@ func activateItem(keepOpen, alt bool) {
 		setStdin(cmd, &entry.PipedAlt)
 	}
 
+	saveUsage(entry, module, input, use)
+
+	return cmd.Start()
+}
+
+// saveUsage records that the entry was chosen for the input.
+func saveUsage(entry util.Entry, module modules.Workable, input string, use history.Use) {
 	identifier := entry.Identifier()
 
 	if entry.History {
-		hstry.Save(identifier, strings.TrimSpace(elements.input.Text()))
+		use.Time = time.Now()
+		hstry.Save(identifier, strings.TrimSpace(input), use)
 	}
 
 	if module != nil && (module.General().History || module.General().Typeahead) {
-		history.SaveInputHistory(module.General().Name, elements.input.Text(), identifier)
+		history.SaveInputHistory(module.General().Name, input, identifier)
 	}
+}
 
-	err := cmd.Start()
-	if err != nil {
-		log.Println(err)
+// showMenu queries the module again after it switched to another level of its menu.
+func showMenu(module modules.Workable) {
+	g := module.General()
+
+	elements.input.SetObjectProperty("placeholder-text", g.Placeholder)
+
+	if elements.input.Text() == g.Prefix {
+		process()
+		return
 	}
 
-	closeAfterActivation(keepOpen, selectNext)
+	elements.input.SetText(g.Prefix)
+	elements.input.SetPosition(-1)
 }
 
 func handleSwitcher(module string) {
@@ -482,15 +598,17 @@ func handleSwitcher(module string) {
 	}
 }
 
-func handleDmenuResult(result string) {
+func handleDmenuResult(code int, result string) {
 	if appstate.IsService {
 		for _, v := range toUse {
 			if v.General().Name == "dmenu" {
-				v.(*modules.Dmenu).Reply(result)
+				v.(*modules.Dmenu).Reply(code, result)
 			}
 		}
 	} else {
-		if result != "CNCLD" {
+		dmenuExitCode = code
+
+		if code != modules.DmenuCancelled {
 			fmt.Println(result)
 		}
 	}
@@ -544,6 +662,7 @@ func disableMouseGtk() {
 
 func process() {
 	disableMouseGtk()
+	hideActions()
 
 	if isAi {
 		return
@@ -556,22 +675,18 @@ func process() {
 	text = trimArgumentDelimiter(text)
 
 	if text == "" && config.Cfg.List.ShowInitialEntries && len(explicits) == 0 && !appstate.IsDmenu {
+		setLoading(nil)
+		setErrors(nil)
 		setInitials()
 		return
 	}
 
 	if (text != "" || appstate.IsDmenu) || (len(explicits) > 0 && config.Cfg.List.ShowInitialEntries) {
-		if !layout.Window.Box.Search.Spinner.Hide {
-			elements.spinner.SetVisible(true)
-		}
-
 		go processAsync(text)
 	} else {
 		common.items.Splice(0, int(common.items.NItems()))
-
-		if !layout.Window.Box.Search.Spinner.Hide {
-			elements.spinner.SetVisible(false)
-		}
+		setLoading(nil)
+		setErrors(nil)
 	}
 }
 
@@ -590,7 +705,7 @@ func timeoutReset() {
 				}
 
 				if appstate.IsDmenu {
-					handleDmenuResult("")
+					handleDmenuResult(0, "")
 				}
 
 				if !isAi && !blockTimeout {
@@ -621,23 +736,12 @@ func handleTimeout() {
 	}
 }
 
-var mut sync.Mutex
-
 func processAsync(text string) {
-	entries := []util.Entry{}
-
-	defer func() {
-		if !layout.Window.Box.Search.Spinner.Hide {
-			elements.spinner.SetVisible(false)
-		}
-	}()
-
 	hasExplicit := len(explicits) > 0
 
 	p := toUse
 
 	query := text
-	hasPrefix := false
 
 	prefixes := []string{}
 
@@ -646,12 +750,11 @@ func processAsync(text string) {
 
 		if len(prefix) > 0 && strings.HasPrefix(text, prefix) {
 			prefixes = append(prefixes, prefix)
-			hasPrefix = true
 		}
 	}
 
 	if !hasExplicit {
-		if hasPrefix {
+		if len(prefixes) > 0 {
 			glib.IdleAdd(func() {
 				for _, v := range prefixes {
 					elements.appwin.SetCSSClasses(elements.prefixClasses[v])
@@ -664,9 +767,6 @@ func processAsync(text string) {
 
 	setTypeahead(p)
 
-	var wg sync.WaitGroup
-	wg.Add(len(p))
-
 	keepSort := false || appstate.KeepSort
 
 	if len(p) == 1 {
@@ -674,226 +774,61 @@ func processAsync(text string) {
 		appstate.IsSingle = true
 	}
 
-	hasEntryPrefix := false
+	single := ""
 
-	for k := range p {
-		if p[k] == nil {
-			wg.Done()
-			continue
+	if singleModule != nil {
+		single = singleModule.General().Name
+	}
+
+	engine.Stream(newQueryContext(), engine.Query{
+		Text:        text,
+		Modules:     p,
+		KeepSort:    keepSort,
+		Single:      single,
+		IsDmenu:     appstate.IsDmenu,
+		Accepted:    tahAcceptedIdentifier,
+		MarkerColor: layout.Window.Box.Scroll.List.MarkerColor,
+		History:     hstry,
+		Context:     usageContext,
+		Debug:       appstate.IsDebug,
+	}, func(b engine.Batch) {
+		if query != lastQuery {
+			return
 		}
 
-		if len(p) > 1 {
-			prefix := p[k].General().Prefix
-
-			if p[k].General().SwitcherOnly {
-				if prefix == "" {
-					wg.Done()
-					continue
-				}
-
-				if !strings.HasPrefix(text, prefix) {
-					wg.Done()
-					continue
-				}
-			}
-
-			if hasPrefix && prefix == "" {
-				wg.Done()
-				continue
-			}
-
-			if !hasPrefix && prefix != "" {
-				wg.Done()
-				continue
-			}
-
-			if hasPrefix && !strings.HasPrefix(text, prefix) {
-				wg.Done()
-				continue
-			}
-		}
+		// keep the previous results until something arrived, so the list doesn't flicker
+		if len(b.Entries) == 0 && len(b.Pending) > 0 {
+			glib.IdleAdd(func() {
+				setLoading(b.Pending)
+				setErrors(b.Errors)
+			})
 
-		if !p[k].General().IsSetup {
-			p[k].SetupData()
+			return
 		}
 
-		go func(wg *sync.WaitGroup, text string, w modules.Workable) {
-			defer wg.Done()
-
-			mCfg := w.General()
+		glib.IdleAdd(func() {
+			setLoading(b.Pending)
+			setErrors(b.Errors)
 
-			if len(text) < mCfg.MinChars {
-				return
-			}
-
-			text = strings.TrimPrefix(text, w.General().Prefix)
-
-			e := w.Entries(text)
-
-			toPush := []util.Entry{}
-			g := w.General()
-
-		outer:
-			for k := range e {
-				if len(mCfg.Blacklist) > 0 {
-					for _, b := range mCfg.Blacklist {
-						if !b.Label && !b.Sub {
-							if b.Reg.MatchString(e[k].Label) {
-								continue outer
-							}
-
-							if b.Reg.MatchString(e[k].Sub) {
-								continue outer
-							}
-						}
-
-						if b.Label {
-							if b.Reg.MatchString(e[k].Label) {
-								continue outer
-							}
-						}
-
-						if b.Sub {
-							if b.Reg.MatchString(e[k].Sub) {
-								continue outer
-							}
-						}
-					}
-				}
-
-				if e[k].SingleModuleOnly && singleModule == nil {
-					continue
-				}
-
-				e[k].Module = g.Name
-				e[k].Weight = g.Weight
-
-				toMatch := text
-
-				if e[k].MatchFields > 0 {
-					textFields := strings.Fields(text)
-
-					if len(textFields) > 0 {
-						toMatch = strings.Join(textFields[:1], " ")
-					}
-				}
-
-				if e[k].RecalculateScore {
-					e[k].ScoreFinal = 0
-					e[k].ScoreFuzzy = 0
-				}
+			common.items.Splice(0, int(common.items.NItems()), b.Entries...)
 
-				if e[k].ScoreFinal == 0 {
-					switch e[k].Matching {
-					case util.AlwaysTopOnEmptySearch:
-						if text != "" {
-							e[k].ScoreFinal = fuzzyScore(&e[k], toMatch, g.History)
-						} else {
-							e[k].ScoreFinal = 1000
-						}
-					case util.Fuzzy:
-						e[k].ScoreFinal = fuzzyScore(&e[k], toMatch, g.History)
-					case util.AlwaysTop:
-						if e[k].ScoreFinal == 0 {
-							e[k].ScoreFinal = 1000
-						}
-					case util.AlwaysBottom:
-						if e[k].ScoreFinal == 0 {
-							e[k].ScoreFinal = 1
-						}
-					default:
-						e[k].ScoreFinal = 0
-					}
-				}
-
-				if toMatch == "" {
-					if e[k].ScoreFinal != 0 {
-						if e[k].Prefix != "" && strings.HasPrefix(text, e[k].Prefix) {
-							hasEntryPrefix = true
-
-							toPush = append(toPush, e[k])
-						} else {
-							if e[k].IgnoreUnprefixed {
-								continue
-							}
-
-							toPush = append(toPush, e[k])
-						}
-					}
-				} else {
-					if e[k].ScoreFinal > float64(config.Cfg.List.VisibilityThreshold) {
-						if e[k].Prefix != "" && strings.HasPrefix(text, e[k].Prefix) {
-							hasEntryPrefix = true
-
-							toPush = append(toPush, e[k])
-						} else {
-							if e[k].IgnoreUnprefixed {
-								continue
-							}
-
-							toPush = append(toPush, e[k])
-						}
+			if config.Cfg.IgnoreMouse && !elements.grid.CanTarget() {
+				for _, v := range b.Entries {
+					if v.DragDrop {
+						elements.grid.SetCanTarget(true)
+						break
 					}
 				}
+			} else if config.Cfg.IgnoreMouse {
+				elements.grid.SetCanTarget(false)
 			}
-
-			mut.Lock()
-			entries = append(entries, toPush...)
-			mut.Unlock()
-		}(&wg, text, p[k])
-	}
-
-	wg.Wait()
+		})
+	})
 
 	if query != lastQuery {
 		return
 	}
 
-	if hasEntryPrefix {
-		finalEntries := []util.Entry{}
-
-		for _, v := range entries {
-			if v.Prefix != "" {
-				finalEntries = append(finalEntries, v)
-			}
-		}
-
-		entries = finalEntries
-	}
-
-	if !keepSort || text != "" {
-		sortEntries(entries, keepSort)
-	}
-
-	if len(entries) > config.Cfg.List.MaxEntries {
-		entries = entries[:config.Cfg.List.MaxEntries]
-	}
-
-	if appstate.IsDebug {
-		for _, v := range entries {
-			fmt.Printf("Entries == label: %s sub: %s score: %f\n", v.Label, v.Sub, v.ScoreFinal)
-		}
-	}
-
-	glib.IdleAdd(func() {
-		common.items.Splice(0, int(common.items.NItems()), entries...)
-
-		if config.Cfg.IgnoreMouse && !elements.grid.CanTarget() {
-			for _, v := range entries {
-				if v.DragDrop {
-					elements.grid.SetCanTarget(true)
-					break
-				}
-			}
-		} else if config.Cfg.IgnoreMouse {
-			elements.grid.SetCanTarget(false)
-		}
-
-		if !layout.Window.Box.Search.Spinner.Hide {
-			elements.spinner.SetVisible(false)
-		}
-	})
-
 	tahAcceptedIdentifier = ""
 }
 
@@ -941,7 +876,7 @@ func setInitials() {
 		proc.SetupData()
 	}
 
-	e := proc.Entries("")
+	e := proc.Entries(context.Background(), "")
 
 	for _, entry := range e {
 		entry.Module = proc.General().Name
@@ -950,17 +885,7 @@ func setInitials() {
 		entry.ScoreFinal = 0
 
 		if proc.General().History {
-			for _, v := range hstry {
-				if val, ok := v[entry.Identifier()]; ok {
-					if entry.LastUsed.IsZero() || val.LastUsed.After(entry.LastUsed) {
-						entry.Used = val.Used
-						entry.DaysSinceUsed = val.DaysSinceUsed
-						entry.LastUsed = val.LastUsed
-					}
-				}
-			}
-
-			entry.ScoreFinal = float64(usageModifier(&entry))
+			entry.ScoreFinal = engine.Usage(&entry, hstry, "", proc.General().Frecency, usageContext)
 		}
 
 		entries = append(entries, entry)
@@ -970,27 +895,13 @@ func setInitials() {
 		return
 	}
 
-	sortEntries(entries, false)
+	engine.Sort(entries, "", false, "")
 
 	glib.IdleAdd(func() {
 		common.items.Splice(0, int(common.items.NItems()), entries...)
 	})
 }
 
-func usageModifier(item *util.Entry) int {
-	base := 10
-
-	if item.Used > 0 {
-		if item.DaysSinceUsed > 0 {
-			base -= item.DaysSinceUsed
-		}
-
-		return base * item.Used
-	}
-
-	return 0
-}
-
 func quit(ignoreEvent bool) {
 	if !ignoreEvent {
 		executeEvent(config.EventExit, "")
@@ -1002,6 +913,8 @@ func quit(ignoreEvent bool) {
 
 	timeoutTimer = nil
 
+	wasDmenu := appstate.IsDmenu
+
 	if singleModule != nil {
 		if _, ok := layouts[singleModule.General().Name]; ok {
 			glib.IdleAdd(func() {
@@ -1019,6 +932,8 @@ func quit(ignoreEvent bool) {
 
 	historyIndex = 0
 
+	stopQuery()
+
 	for _, v := range toUse {
 		go v.Cleanup()
 	}
@@ -1029,6 +944,9 @@ func quit(ignoreEvent bool) {
 	appstate.ExplicitPlaceholder = ""
 	appstate.ExplicitTheme = ""
 	appstate.IsDmenu = false
+	appstate.DmenuMulti = false
+
+	marked = nil
 
 	explicits = []modules.Workable{}
 
@@ -1041,6 +959,8 @@ func quit(ignoreEvent bool) {
 			if !layout.Window.Box.Search.Spinner.Hide {
 				elements.spinner.SetVisible(false)
 			}
+
+			setLoading(nil)
 		}
 
 		if !config.Cfg.Search.ResumeLastQuery {
@@ -1059,172 +979,32 @@ func quit(ignoreEvent bool) {
 	isAi = false
 	blockTimeout = false
 
+	if wasDmenu && appstate.IsService {
+		EndDmenuSession()
+	}
+
 	common.app.Hold()
 }
 
 func exit(ignoreEvent bool, cancel bool) {
-	code := 0
+	code := dmenuExitCode
 
 	if cancel {
-		code = 2
+		code = modules.DmenuCancelled
 	}
 
 	if !ignoreEvent {
 		executeEvent(config.EventExit, "")
 	}
 
-	elements.appwin.Close()
-
-	os.Exit(code)
-}
-
-const modifier = 0.10
-
-func fuzzyScore(entry *util.Entry, text string, useHistory bool) float64 {
-	textLength := len(text)
-
-	entry.MatchedLabel = ""
-	entry.MatchedSub = ""
-
-	if entry.Prefix != "" {
-		if strings.HasPrefix(text, entry.Prefix) {
-			text = strings.TrimPrefix(text, entry.Prefix)
-		}
-	}
-
-	if textLength == 0 {
-		return 1
-	}
-
-	var matchables []string
-
-	if !appstate.IsDmenu {
-		matchables = []string{entry.Label, entry.Sub, entry.Searchable, entry.Searchable2}
-		matchables = append(matchables, entry.Categories...)
-	} else {
-		matchables = []string{entry.Label}
-	}
-
-	multiplier := 0
-
-	var pos *[]int
-
-	for k, t := range matchables {
-		if t == "" {
-			continue
-		}
-
-		remember := ""
-
-		if k == 0 && singleModule != nil && singleModule.General().Name == config.Cfg.Builtins.Emojis.Name {
-			remember = strings.Fields(t)[0]
-			t = entry.Searchable
-		}
-
-		var score float64
-
-		if strings.HasPrefix(text, "'") {
-			cleanText := strings.TrimPrefix(text, "'")
-
-			score, _ = util.ExactScore(cleanText, t)
-
-			f := strings.Index(strings.ToLower(t), strings.ToLower(cleanText))
-
-			if f != -1 {
-				poss := []int{}
-
-				for i := f; i < f+len(text); i++ {
-					poss = append(poss, i)
-				}
-
-				pos = &poss
-			}
-		} else {
-			score, pos = util.FuzzyScore(text, t)
-		}
-
-		if score < 2 {
-			continue
-		}
-
-		if score > entry.ScoreFuzzy {
-			multiplier = k
-
-			if config.Cfg.List.DynamicSub && k > 1 {
-				entry.MatchedSub = t
-			}
-
-			if layout.Window.Box.Scroll.List.MarkerColor != "" {
-				res := ""
-
-				if pos != nil {
-					for k, v := range t {
-						if slices.Contains(*pos, k) {
-							res = fmt.Sprintf("%s<span color=\"%s\">%s</span>", res, layout.Window.Box.Scroll.List.MarkerColor, string(v))
-						} else {
-							res = fmt.Sprintf("%s%s", res, string(v))
-						}
-					}
-				}
-
-				if remember != "" {
-					res = fmt.Sprintf("%s %s", remember, res)
-				}
-
-				if k == 0 {
-					entry.MatchedLabel = res
-				} else if k > 0 {
-					entry.MatchedSub = res
-				}
-			}
-
-			entry.ScoreFuzzy = score
-		}
-	}
-
-	if entry.ScoreFuzzy == 0 {
-		return 0
+	// the modules registered the ids of their entries by now, so their records are moved before the next run
+	if hstry != nil {
+		hstry.Migrate()
 	}
 
-	m := (1 - modifier*float64(multiplier))
-
-	if m < 0.7 {
-		m = 0.7
-	}
-
-	entry.ScoreFuzzy = entry.ScoreFuzzy * m
-
-	usageScore := 0
-
-	if useHistory {
-		for k, v := range hstry {
-			if strings.HasPrefix(k, text) {
-				if val, ok := v[entry.Identifier()]; ok {
-					if entry.LastUsed.IsZero() || val.LastUsed.After(entry.LastUsed) {
-						entry.Used = val.Used
-						entry.DaysSinceUsed = val.DaysSinceUsed
-						entry.LastUsed = val.LastUsed
-					}
-				}
-			}
-		}
-
-		usageScore = usageModifier(entry)
-	}
-
-	if textLength == 0 {
-		textLength = 1
-	}
-
-	tm := 1.0 / float64(textLength)
-
-	score := float64(usageScore)*tm + float64(entry.ScoreFuzzy)/tm
-
-	if appstate.IsDebug {
-		fmt.Printf("Matching == label: %s sub: %s searchable: %s categories: %s score: %f usage: %d fuzzy: %f m: %f\n", entry.Label, entry.Sub, entry.Searchable, entry.Categories, score, usageScore, entry.ScoreFuzzy, m)
-	}
+	elements.appwin.Close()
 
-	return score
+	os.Exit(code)
 }
 
 func wrapWithPrefix(text string) string {
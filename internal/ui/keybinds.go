@@ -50,6 +50,7 @@ var (
 		"up":        int(gdk.KEY_Up),
 		"left":      int(gdk.KEY_Left),
 		"right":     int(gdk.KEY_Right),
+		"space":     int(gdk.KEY_space),
 	}
 
 	labelTrigger        = gdk.KEY_Alt_L
@@ -69,6 +70,7 @@ func parseKeybinds() {
 
 	for _, v := range config.Cfg.Keys.Close {
 		binds.validate(v)
+		binds.bind(binds, v, closeActions)
 		binds.bind(binds, v, quitKeybind)
 	}
 
@@ -97,6 +99,21 @@ func parseKeybinds() {
 		binds.bind(binds, v, toggleExactMatch)
 	}
 
+	for _, v := range config.Cfg.Keys.ToggleActions {
+		binds.validate(v)
+		binds.bind(binds, v, toggleActions)
+	}
+
+	for _, v := range config.Cfg.Keys.ToggleMark {
+		binds.validate(v)
+		binds.bind(binds, v, toggleMark)
+	}
+
+	for k, v := range config.Cfg.Keys.DmenuCustom {
+		binds.validate(v)
+		binds.bind(binds, v, dmenuCustom(k))
+	}
+
 	binds.bind(binds, "enter", func() bool { return activate(false, false) })
 	binds.bind(binds, strings.Join([]string{config.Cfg.Keys.ActivationModifiers.KeepOpen, "enter"}, " "), func() bool { return activate(true, false) })
 	binds.bind(binds, strings.Join([]string{config.Cfg.Keys.ActivationModifiers.Alternate, "enter"}, " "), func() bool { return activate(false, true) })
@@ -130,6 +147,18 @@ func parseKeybinds() {
 }
 
 func (keybinds) bind(binds keybinds, val string, fn func() bool) {
+	key, modifier := parseBind(val)
+
+	_, ok := binds[key]
+	if !ok {
+		binds[key] = make(map[gdk.ModifierType][]func() bool)
+	}
+
+	binds[key][modifier] = append(binds[key][modifier], fn)
+}
+
+// parseBind returns the key and the modifiers of a keybind like "ctrl shift k".
+func parseBind(val string) (int, gdk.ModifierType) {
 	fields := strings.Fields(val)
 
 	m := []gdk.ModifierType{}
@@ -161,12 +190,7 @@ func (keybinds) bind(binds keybinds, val string, fn func() bool) {
 		modifier = m[0] | m[1] | m[2]
 	}
 
-	_, ok := binds[key]
-	if !ok {
-		binds[key] = make(map[gdk.ModifierType][]func() bool)
-	}
-
-	binds[key][modifier] = append(binds[key][modifier], fn)
+	return key, modifier
 }
 
 func (keybinds) execute(key int, modifier gdk.ModifierType) bool {
@@ -338,7 +362,7 @@ func activateKeepOpenFunctionKeys(val uint) bool {
 
 func quitKeybind() bool {
 	if appstate.IsDmenu {
-		handleDmenuResult("CNCLD")
+		handleDmenuResult(modules.DmenuCancelled, "")
 	}
 
 	if config.Cfg.IsService {
@@ -367,7 +391,7 @@ func acceptTypeahead() bool {
 func activate(keepOpen bool, isAlt bool) bool {
 	if appstate.ForcePrint && elements.grid.Model().NItems() == 0 {
 		if appstate.IsDmenu {
-			handleDmenuResult(elements.input.Text())
+			handleDmenuResult(dmenuExitCode, dmenuOutput(elements.input.Text(), -1))
 		}
 
 		closeAfterActivation(keepOpen, false)
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v4"
+)
+
+var loadingSpinners = make(map[string]*gtk.Spinner)
+
+// setLoading shows a spinner for every module that is still loading. Spinners are named "spinner" and carry the module's name as class.
+func setLoading(pending []string) {
+	if layout == nil || layout.Window.Box.Search.Spinner.Hide {
+		return
+	}
+
+	for name, spinner := range loadingSpinners {
+		if !slices.Contains(pending, name) {
+			spinner.SetSpinning(false)
+			spinner.SetVisible(false)
+		}
+	}
+
+	for _, name := range pending {
+		spinner, ok := loadingSpinners[name]
+		if !ok {
+			spinner = gtk.NewSpinner()
+			spinner.SetName("spinner")
+			spinner.AddCSSClass(name)
+			spinner.SetTooltipText(name)
+
+			setupWidgetStyle(&spinner.Widget, &layout.Window.Box.Search.Spinner.Widget, false)
+
+			loadingSpinners[name] = spinner
+			elements.loading.Append(spinner)
+		}
+
+		spinner.SetSpinning(true)
+		spinner.SetVisible(true)
+	}
+}
+
+var errorIcons = make(map[string]*gtk.Image)
+
+// setErrors shows a warning icon for every module whose query failed, the error is shown as tooltip. Icons are named "error" and carry the module's name as class.
+func setErrors(errs map[string]error) {
+	if layout == nil {
+		return
+	}
+
+	for name, icon := range errorIcons {
+		if _, ok := errs[name]; !ok {
+			icon.SetVisible(false)
+		}
+	}
+
+	for name, err := range errs {
+		icon, ok := errorIcons[name]
+		if !ok {
+			icon = gtk.NewImageFromIconName("dialog-warning-symbolic")
+			icon.SetName("error")
+			icon.AddCSSClass(name)
+
+			errorIcons[name] = icon
+			elements.loading.Append(icon)
+		}
+
+		icon.SetTooltipText(fmt.Sprintf("%s: %s", name, err))
+		icon.SetVisible(true)
+	}
+}
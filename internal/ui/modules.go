@@ -2,23 +2,24 @@ package ui
 
 import (
 	"fmt"
-	"log"
 	"os"
-	"regexp"
 	"slices"
 	"time"
 
 	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/engine"
 	"github.com/abenz1267/walker/internal/modules"
-	"github.com/abenz1267/walker/internal/modules/emojis"
-	"github.com/abenz1267/walker/internal/modules/symbols"
-	"github.com/abenz1267/walker/internal/modules/windows"
 	"github.com/abenz1267/walker/internal/util"
 	"github.com/diamondburned/gotk4/pkg/glib/v2"
 )
 
 func setupModules() {
-	setAvailables()
+	if len(available) == 0 {
+		setAvailables()
+	} else {
+		syncModules()
+	}
+
 	toUse = []modules.Workable{}
 
 	if len(appstate.ExplicitModules) > 0 {
@@ -58,22 +59,10 @@ func setupModules() {
 		go setupLayouts(checkForLayout)
 	}
 
-	prepareBlacklists()
+	engine.PrepareBlacklists(available)
 	setupSingleModule()
 }
 
-func prepareBlacklists() {
-	for k, v := range available {
-		c := v.General()
-
-		if len(c.Blacklist) > 0 {
-			for n, b := range c.Blacklist {
-				available[k].General().Blacklist[n].Reg = regexp.MustCompile(b.Regexp)
-			}
-		}
-	}
-}
-
 func setupLayouts(modules []modules.Workable) {
 	for _, v := range modules {
 		g := v.General()
@@ -84,63 +73,7 @@ func setupLayouts(modules []modules.Workable) {
 }
 
 func setAvailables() {
-	res := []modules.Workable{
-		&modules.Applications{Hstry: hstry},
-		&modules.Bookmarks{},
-		&modules.AI{},
-		&modules.Runner{},
-		&modules.Websearch{},
-		&modules.Calc{},
-		&modules.Commands{},
-		&modules.SSH{},
-		&modules.Finder{MarkerColor: layout.Window.Box.Scroll.List.MarkerColor},
-		&modules.Switcher{},
-		&emojis.Emojis{},
-		&symbols.Symbols{},
-		&modules.CustomCommands{},
-		&windows.Windows{},
-	}
-
-	if os.Getenv("XDG_CURRENT_DESKTOP") == "Hyprland" {
-		res = append(res, &modules.XdphPicker{})
-	}
-
-	if !appstate.IsService {
-		res = append(res, &modules.Dmenu{})
-	}
-
-	for _, v := range config.Cfg.Plugins {
-		e := &modules.Plugin{}
-		e.Config = v
-
-		res = append(res, e)
-	}
-
-	available = []modules.Workable{}
-	config.Cfg.Hidden = []string{}
-
-	for _, v := range res {
-		if v == nil {
-			continue
-		}
-
-		if ok := v.Setup(); ok {
-			if v.General().Name == "" {
-				log.Panicln("module has no name\n")
-			}
-
-			if slices.Contains(config.Cfg.Disabled, v.General().Name) {
-				continue
-			}
-
-			available = append(available, v)
-			config.Cfg.Available = append(config.Cfg.Available, v.General().Name)
-
-			if v.General().Hidden {
-				config.Cfg.Hidden = append(config.Cfg.Hidden, v.General().Name)
-			}
-		}
-	}
+	available = engine.Available(hstry, markerColor(), !appstate.IsService)
 
 	if appstate.IsService {
 		if appstate.Dmenu != nil {
@@ -155,6 +88,34 @@ func setAvailables() {
 	}
 }
 
+// markerColor returns the marker color of the layout. The control socket can set up the modules before the window
+// loaded it, the configured theme is used then.
+func markerColor() string {
+	l := layout
+
+	if l == nil {
+		l, _ = config.GetLayout(config.Cfg.Theme, config.Cfg.ThemeBase)
+	}
+
+	if l == nil {
+		return ""
+	}
+
+	return l.Window.Box.Scroll.List.MarkerColor
+}
+
+// syncModules hands the current history and layout to modules that were set up before, f.e. by the control socket.
+func syncModules() {
+	for _, v := range available {
+		switch m := v.(type) {
+		case *modules.Applications:
+			m.Hstry = hstry
+		case *modules.Finder:
+			m.MarkerColor = markerColor()
+		}
+	}
+}
+
 func findModule(name string, modules ...[]modules.Workable) modules.Workable {
 	for _, v := range modules {
 		for _, w := range v {
@@ -408,9 +408,11 @@ func setupInputTheme() {
 	} else {
 		if layout.Window.Box.Search.Revert {
 			elements.search.Append(elements.spinner)
+			elements.search.Append(elements.loading)
 			elements.search.Append(elements.overlay)
 		} else {
 			elements.search.Append(elements.overlay)
+			elements.search.Append(elements.loading)
 			elements.search.Append(elements.spinner)
 		}
 	}
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/abenz1267/walker/internal/config"
+	"github.com/abenz1267/walker/internal/engine"
 	"github.com/abenz1267/walker/internal/history"
 	"github.com/abenz1267/walker/internal/modules"
 	"github.com/abenz1267/walker/internal/state"
@@ -41,6 +42,7 @@ var (
 	toUse             []modules.Workable
 	available         []modules.Workable
 	hstry             history.History
+	usageContext      history.Use
 	appstate          *state.AppState
 	thumbnails        map[string][]byte
 	thumbnailsMutex   sync.Mutex
@@ -64,6 +66,7 @@ type Elements struct {
 	scroll          *gtk.ScrolledWindow
 	overlay         *gtk.Overlay
 	spinner         *gtk.Spinner
+	loading         *gtk.Box
 	search          *gtk.Box
 	bar             *gtk.Box
 	cfgErr          *gtk.Label
@@ -97,6 +100,7 @@ func Activate(state *state.AppState) func(app *gtk.Application) {
 		layouts = make(map[string]*config.UI)
 
 		hstry = history.Get()
+		usageContext = engine.CurrentContext()
 
 		if appstate.IsService {
 			cfgErr = appstate.ConfigError
@@ -141,6 +145,14 @@ func Activate(state *state.AppState) func(app *gtk.Application) {
 			if appstate.DmenuLabelColumn != 0 {
 				config.Cfg.Builtins.Dmenu.LabelColumn = appstate.DmenuLabelColumn
 			}
+
+			if appstate.DmenuFormat != "" {
+				config.Cfg.Builtins.Dmenu.Format = appstate.DmenuFormat
+			}
+
+			if appstate.DmenuJsonLines {
+				config.Cfg.Builtins.Dmenu.JsonLines = true
+			}
 		}
 
 		if appstate.ExplicitPlaceholder != "" {
@@ -312,6 +324,9 @@ func setupElements(app *gtk.Application) *Elements {
 	spinner := gtk.NewSpinner()
 	spinner.SetName("spinner")
 
+	loading := gtk.NewBox(gtk.OrientationHorizontal, 0)
+	loading.SetName("loading")
+
 	search := gtk.NewBox(gtk.OrientationHorizontal, 0)
 	typeahead := gtk.NewEntry()
 	typeahead.SetCanFocus(false)
@@ -365,6 +380,7 @@ func setupElements(app *gtk.Application) *Elements {
 		bar:             bar,
 		overlay:         overlay,
 		spinner:         spinner,
+		loading:         loading,
 		search:          search,
 		typeahead:       typeahead,
 		scroll:          scroll,
@@ -520,6 +536,10 @@ func setupFactory() *gtk.SignalListItemFactory {
 			}
 		}
 
+		if appstate.IsDmenu && isMarked(val) {
+			boxClasses = append(boxClasses, "marked")
+		}
+
 		box.SetCSSClasses(boxClasses)
 
 		var icon *gtk.Image
@@ -773,9 +793,17 @@ func reopen() {
 	}
 
 	appstate.IsRunning = true
+	usageContext = engine.CurrentContext()
+
+	// entries that got a stable id during the last session take their history along
+	hstry.Migrate()
 
 	go func() {
 		for _, proc := range toUse {
+			if o, ok := proc.(modules.Opener); ok {
+				o.Open()
+			}
+
 			if proc.General().HasInitialSetup {
 				proc.Refresh()
 			}
@@ -0,0 +1,87 @@
+package util
+
+type ActionType string
+
+const (
+	ActionExec     ActionType = "exec"
+	ActionTerminal ActionType = "terminal"
+	ActionCopy     ActionType = "copy"
+	ActionOpen     ActionType = "open"
+	ActionReveal   ActionType = "reveal"
+	ActionFunc     ActionType = "func"
+)
+
+// ActionRunAlt is the name of the implied action running ExecAlt.
+const ActionRunAlt = "run_alt"
+
+// Action is something that can be done with an entry.
+// "exec" and "terminal" run Exec, "copy" copies Value, "open" opens Value with xdg-open and "reveal" opens the directory containing it.
+// "func" calls Func for builtin modules, plugins get such actions handed back instead.
+type Action struct {
+	Name     string     `mapstructure:"name" json:"name" koanf:"name"`
+	Label    string     `mapstructure:"label" json:"label" koanf:"label"`
+	Type     ActionType `mapstructure:"type" json:"type" koanf:"type"`
+	Key      string     `mapstructure:"key,omitempty" json:"key,omitempty" koanf:"key"`
+	Exec     string     `mapstructure:"exec,omitempty" json:"exec,omitempty" koanf:"exec"`
+	Value    string     `mapstructure:"value,omitempty" json:"value,omitempty" koanf:"value"`
+	KeepOpen bool       `mapstructure:"keep_open,omitempty" json:"keep_open,omitempty" koanf:"keep_open"`
+
+	// internal
+	Func func(term string) `mapstructure:"-" json:"-" koanf:"-"`
+}
+
+// AllActions returns the actions implied by Exec, ExecAlt and SpecialFunc, followed by the declared ones.
+func (e Entry) AllActions() []Action {
+	res := []Action{}
+
+	switch {
+	case e.SpecialFunc != nil:
+		res = append(res, Action{
+			Name:  "activate",
+			Label: "Activate",
+			Type:  ActionFunc,
+			Func: func(term string) {
+				args := append([]interface{}{}, e.SpecialFuncArgs...)
+				e.SpecialFunc(append(args, term)...)
+			},
+		})
+	case e.Exec != "":
+		res = append(res, Action{
+			Name:  "run",
+			Label: "Run",
+			Type:  ActionExec,
+			Exec:  e.Exec,
+		})
+
+		if e.ExecAlt != "" {
+			res = append(res, Action{
+				Name:  ActionRunAlt,
+				Label: "Run alternative",
+				Type:  ActionExec,
+				Exec:  e.ExecAlt,
+			})
+		}
+
+		if !e.Terminal {
+			res = append(res, Action{
+				Name:  "terminal",
+				Label: "Run in terminal",
+				Type:  ActionTerminal,
+				Exec:  e.Exec,
+			})
+		}
+	}
+
+	return append(res, e.Actions...)
+}
+
+// FindAction returns the action with the given name.
+func (e Entry) FindAction(name string) (Action, bool) {
+	for _, v := range e.AllActions() {
+		if v.Name == name {
+			return v, true
+		}
+	}
+
+	return Action{}, false
+}
@@ -1,7 +1,10 @@
 package util
 
 import (
+	"regexp"
 	"strings"
+	"sync"
+	"unicode"
 
 	"github.com/junegunn/fzf/src/algo"
 	"github.com/junegunn/fzf/src/util"
@@ -20,3 +23,306 @@ func ExactScore(input, target string) (float64, *[]int) {
 
 	return float64(res.Score - res.Start), pos
 }
+
+const (
+	MatcherFuzzy     = "fuzzy"
+	MatcherPrefix    = "prefix"
+	MatcherSubstring = "substring"
+	MatcherInitials  = "initials"
+	MatcherRegex     = "regex"
+	MatcherTokens    = "tokens"
+)
+
+// Match scores target against input with the given matcher, falling back to fuzzy matching.
+func Match(matcher, input, target string) (float64, *[]int) {
+	switch matcher {
+	case MatcherPrefix:
+		return PrefixScore(input, target)
+	case MatcherSubstring:
+		return ExactScore(input, target)
+	case MatcherInitials:
+		return InitialsScore(input, target)
+	case MatcherRegex:
+		return RegexScore(input, target)
+	case MatcherTokens:
+		return TokensScore(input, target)
+	default:
+		return FuzzyScore(input, target)
+	}
+}
+
+func PrefixScore(input, target string) (float64, *[]int) {
+	return algoScore(algo.PrefixMatch, input, target)
+}
+
+func SuffixScore(input, target string) (float64, *[]int) {
+	return algoScore(algo.SuffixMatch, input, target)
+}
+
+func EqualScore(input, target string) (float64, *[]int) {
+	return algoScore(algo.EqualMatch, input, target)
+}
+
+// algoScore returns nil positions if there's no match.
+func algoScore(fn algo.Algo, input, target string) (float64, *[]int) {
+	chars := util.ToChars([]byte(target))
+	res, pos := fn(false, true, true, &chars, []rune(strings.ToLower(input)), true, nil)
+
+	if res.Start < 0 {
+		return 0, nil
+	}
+
+	if pos == nil {
+		poss := []int{}
+
+		for i := res.Start; i < res.End; i++ {
+			poss = append(poss, i)
+		}
+
+		pos = &poss
+	}
+
+	return float64(res.Score - res.Start), pos
+}
+
+// initialsScore is the score per matched initial, roughly what fzf gives a character matched on a word boundary.
+const initialsScore = 24
+
+// InitialsScore matches input against the first letters of the words in target, so "vsc" matches "Visual Studio Code".
+// Words are separated by non-alphanumeric characters or a change from lower to upper case.
+func InitialsScore(input, target string) (float64, *[]int) {
+	input = strings.ToLower(strings.ReplaceAll(input, " ", ""))
+
+	if input == "" {
+		return 0, nil
+	}
+
+	pos := []int{}
+	initials := []rune{}
+
+	var prev rune
+
+	for k, r := range target {
+		isWordChar := unicode.IsLetter(r) || unicode.IsDigit(r)
+
+		if isWordChar && (k == 0 || !(unicode.IsLetter(prev) || unicode.IsDigit(prev)) || (unicode.IsLower(prev) && unicode.IsUpper(r))) {
+			initials = append(initials, unicode.ToLower(r))
+			pos = append(pos, k)
+		}
+
+		prev = r
+	}
+
+	in := []rune(input)
+
+	if len(in) > len(initials) || string(initials[:len(in)]) != input {
+		return 0, nil
+	}
+
+	pos = pos[:len(in)]
+
+	return float64(len(in) * initialsScore), &pos
+}
+
+var (
+	regexCache   = make(map[string]*regexp.Regexp)
+	regexCacheMu sync.Mutex
+)
+
+// RegexScore matches input as a case-insensitive regular expression. Incomplete expressions don't match.
+func RegexScore(input, target string) (float64, *[]int) {
+	regexCacheMu.Lock()
+
+	reg, ok := regexCache[input]
+	if !ok {
+		if len(regexCache) > 100 {
+			clear(regexCache)
+		}
+
+		reg, _ = regexp.Compile("(?i)" + input)
+		regexCache[input] = reg
+	}
+
+	regexCacheMu.Unlock()
+
+	if reg == nil {
+		return 0, nil
+	}
+
+	loc := reg.FindStringIndex(target)
+	if loc == nil {
+		return 0, nil
+	}
+
+	pos := []int{}
+
+	for i := loc[0]; i < loc[1]; i++ {
+		pos = append(pos, i)
+	}
+
+	return float64(max(loc[1]-loc[0], 1)*16 - min(loc[0], 15)), &pos
+}
+
+type token struct {
+	text    string
+	inverse bool
+	exact   bool
+	prefix  bool
+	suffix  bool
+}
+
+// TokensScore matches input with fzf's extended search syntax: space separated terms must all match in any order,
+// "|" separates alternatives, "'" matches exactly, "^" and "$" anchor to the start and end and "!" inverts a term.
+func TokensScore(input, target string) (float64, *[]int) {
+	score, pos := TokensScoreFields(input, []string{target}, []float64{1})
+	if pos == nil {
+		return 0, nil
+	}
+
+	return score, &pos[0]
+}
+
+// TokensScoreFields matches input like TokensScore, but against all fields of an entry at once: every term has to
+// match in one of the fields and an inverted term that matches any field rules the entry out. A term scores by the best
+// field it matches, multiplied by the field's weight. Fields with a weight of 0 are skipped. The positions are per field.
+func TokensScoreFields(input string, fields []string, weights []float64) (float64, [][]int) {
+	score := 0.0
+	pos := make([][]int, len(fields))
+
+	for _, group := range parseTokens(input) {
+		matched := false
+		best := 0.0
+		bestField := -1
+
+		var bestPos *[]int
+
+		for _, t := range group {
+			if t.inverse {
+				if !t.matchesAny(fields, weights) {
+					matched = true
+				}
+
+				continue
+			}
+
+			for k, f := range fields {
+				if f == "" || weights[k] <= 0 {
+					continue
+				}
+
+				s, p := t.match(f)
+				if p == nil {
+					continue
+				}
+
+				matched = true
+				s *= weights[k]
+
+				if bestPos == nil || s > best {
+					best = s
+					bestField = k
+					bestPos = p
+				}
+			}
+		}
+
+		if !matched {
+			return 0, nil
+		}
+
+		score += best
+
+		if bestPos != nil {
+			pos[bestField] = append(pos[bestField], *bestPos...)
+		}
+	}
+
+	// a query with only inverse terms still has to clear the minimum score
+	return max(score, 2), pos
+}
+
+func parseTokens(input string) [][]token {
+	groups := [][]token{}
+	group := []token{}
+	or := false
+
+	for _, v := range strings.Fields(input) {
+		if v == "|" {
+			or = true
+			continue
+		}
+
+		t := token{}
+
+		if strings.HasPrefix(v, "!") {
+			t.inverse = true
+			t.exact = true
+			v = v[1:]
+		}
+
+		switch {
+		case strings.HasPrefix(v, "'"):
+			t.exact = true
+			v = v[1:]
+		case strings.HasPrefix(v, "^"):
+			t.prefix = true
+			v = v[1:]
+		}
+
+		if len(v) > 1 && strings.HasSuffix(v, "$") {
+			t.suffix = true
+			v = v[:len(v)-1]
+		}
+
+		if v == "" {
+			continue
+		}
+
+		t.text = v
+
+		if !or && len(group) > 0 {
+			groups = append(groups, group)
+			group = []token{}
+		}
+
+		group = append(group, t)
+		or = false
+	}
+
+	if len(group) > 0 {
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// matchesAny reports whether the token matches any of the fields that aren't skipped.
+func (t token) matchesAny(fields []string, weights []float64) bool {
+	for k, f := range fields {
+		if f == "" || weights[k] <= 0 {
+			continue
+		}
+
+		if _, p := t.match(f); p != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// match returns nil positions if the token doesn't match.
+func (t token) match(target string) (float64, *[]int) {
+	switch {
+	case t.prefix && t.suffix:
+		return algoScore(algo.EqualMatch, t.text, target)
+	case t.prefix:
+		return algoScore(algo.PrefixMatch, t.text, target)
+	case t.suffix:
+		return algoScore(algo.SuffixMatch, t.text, target)
+	case t.exact:
+		return algoScore(algo.ExactMatchNaive, t.text, target)
+	default:
+		return algoScore(algo.FuzzyMatchV2, t.text, target)
+	}
+}
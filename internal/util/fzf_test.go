@@ -0,0 +1,55 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/junegunn/fzf/src/algo"
+)
+
+func init() {
+	algo.Init("default")
+}
+
+func TestTokensScoreFields(t *testing.T) {
+	fields := []string{"Firefox", "Web Browser", "mozilla"}
+	weights := []float64{1, 0.9, 0.8}
+
+	tests := []struct {
+		name  string
+		input string
+		match bool
+	}{
+		{"terms in different fields", "fire brow", true},
+		{"all terms in one field", "fire fox", true},
+		{"missing term", "fire chrome", false},
+		{"inverse term matching another field", "fire !browser", false},
+		{"inverse term matching nothing", "fire !chrome", true},
+		{"only inverse terms", "!chrome", true},
+		{"alternatives", "chrome | moz", true},
+		{"anchored", "^web", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, _ := TokensScoreFields(tt.input, fields, weights)
+
+			if got := score >= 2; got != tt.match {
+				t.Errorf("got match %v with score %f, want %v", got, score, tt.match)
+			}
+		})
+	}
+}
+
+func TestTokensScoreFieldsSkipsExcluded(t *testing.T) {
+	fields := []string{"Firefox", "Web Browser"}
+
+	score, _ := TokensScoreFields("fire brow", fields, []float64{1, 0})
+	if score != 0 {
+		t.Errorf("excluded field matched, score %f", score)
+	}
+
+	score, _ = TokensScoreFields("fire !brow", fields, []float64{1, 0})
+	if score < 2 {
+		t.Error("excluded field vetoed the entry")
+	}
+}
@@ -19,6 +19,7 @@ const (
 )
 
 type Entry struct {
+	Actions           []Action     `mapstructure:"actions,omitempty" json:"actions,omitempty"`
 	Categories        []string     `mapstructure:"categories,omitempty" json:"categories,omitempty"`
 	Class             string       `mapstructure:"class,omitempty" json:"class,omitempty"`
 	DragDrop          bool         `mapstructure:"drag_drop,omitempty" json:"drag_drop,omitempty"`
@@ -27,6 +28,7 @@ type Entry struct {
 	Exec              string       `mapstructure:"exec,omitempty" json:"exec,omitempty"`
 	ExecAlt           string       `mapstructure:"exec_alt,omitempty" json:"exec_alt,omitempty"`
 	HideText          bool         `mapstructure:"hide_text,omitempty" json:"hide_text,omitempty"`
+	ID                string       `mapstructure:"id,omitempty" json:"id,omitempty"`
 	Icon              string       `mapstructure:"icon,omitempty" json:"icon,omitempty"`
 	Image             string       `mapstructure:"image,omitempty" json:"image,omitempty"`
 	InitialClass      string       `mapstructure:"initial_class,omitempty" json:"initial_class,omitempty"`
@@ -52,8 +54,10 @@ type Entry struct {
 	File             string                    `mapstructure:"-"`
 	History          bool                      `mapstructure:"-"`
 	IgnoreUnprefixed bool                      `mapstructure:"-"`
+	Index            int                       `mapstructure:"-"`
 	IsAction         bool                      `mapstructure:"-"`
 	LastUsed         time.Time                 `mapstructure:"-"`
+	Nonselectable    bool                      `mapstructure:"-"`
 	Module           string                    `mapstructure:"-"`
 	OpenWindows      uint                      `mapstructure:"-"`
 	Piped            Piped                     `mapstructure:"-"`
@@ -68,7 +72,17 @@ type Entry struct {
 	Weight           int                       `mapstructure:"-"`
 }
 
+// Identifier is the stable id of the entry if its module supplied one, otherwise it's derived from the displayed text.
 func (e Entry) Identifier() string {
+	if e.ID != "" {
+		return e.ID
+	}
+
+	return e.LegacyIdentifier()
+}
+
+// LegacyIdentifier is the hash of label, sub, searchable and categories, which was used before entries had ids.
+func (e Entry) LegacyIdentifier() string {
 	str := fmt.Sprintf("%s %s %s %s", e.Label, e.Sub, e.Searchable, strings.Join(e.Categories, " "))
 
 	hash := md5.Sum([]byte(str))